@@ -6,12 +6,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/teamsnap/vault-key/pkg/vault"
 )
 
-var env = map[string]map[string]string{}
-
 //export GetSecrets
 func GetSecrets(secretNames *C.char) *C.char {
 	ctx := context.Background()
@@ -23,9 +22,19 @@ func GetSecrets(secretNames *C.char) *C.char {
 		panic(err)
 	}
 
-	vault.GetSecrets(ctx, &env, envArr)
+	// Every path is optional, so one missing secret doesn't hide the others.
+	results, err := vault.GetSecretResults(ctx, nil, envArr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+
+	for _, r := range results {
+		if !r.OK() {
+			fmt.Fprintln(os.Stderr, r.Err.Error())
+		}
+	}
 
-	secretData, err := json.Marshal(env)
+	secretData, err := json.Marshal(results.Values())
 	if err != nil {
 		fmt.Println(err.Error())
 		return C.CString("")
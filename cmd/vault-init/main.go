@@ -2,12 +2,12 @@ package main
 
 import (
 	"context"
-	"github.com/teamsnap/vault-key/pkg/vault"
-	log "github.com/sirupsen/logrus"
 	"os"
-)
+	"strings"
 
-var env = map[string]map[string]string{}
+	log "github.com/sirupsen/logrus"
+	"github.com/teamsnap/vault-key/pkg/vault"
+)
 
 // writeStringToFile takes a file path and a content string and writes the
 // content to a file with the given path
@@ -25,7 +25,36 @@ func writeStringToFile(filePath, str string) {
 	f.Sync()
 }
 
+// dataDir is the shared volume the secrets and certificates are written to.
+const dataDir = "/usr/share/vault/data"
+
+// envList returns the comma separated values of the environment variable n.
+func envList(n string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(n), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// configureLogging logs JSON at warning level in production, and text at
+// trace level everywhere else.
+func configureLogging() {
+	if os.Getenv("ENVIRONMENT") == "production" {
+		log.SetFormatter(&log.JSONFormatter{})
+		log.SetLevel(log.WarnLevel)
+	} else {
+		log.SetFormatter(&log.TextFormatter{})
+		log.SetLevel(log.TraceLevel)
+	}
+}
+
 func main() {
+	configureLogging()
+
 	ctx := context.Background()
 
 	vaultSecret := os.Getenv("VAULT_SECRET")
@@ -34,11 +63,35 @@ func main() {
 		log.Fatal("You need to set VAULT_SECRET environment variable.")
 	}
 
-	var envArr = []string{
-		vaultSecret,
+	// one client, so vault is logged in to once for the certificate and the
+	// secrets
+	client, err := vault.NewClient(ctx)
+	if err != nil {
+		log.Fatal("Error creating Vault client: ", err)
 	}
+	defer client.Close()
+
+	writeCertificate(ctx, client)
 
-	vault.GetSecrets(ctx, &env, envArr)
+	results, err := client.GetSecretResults(ctx, []string{vaultSecret}, envList("VAULT_OPTIONAL_SECRETS"))
+	if err != nil {
+		log.Fatal("Error getting secrets from Vault: ", err)
+	}
+
+	// Merge from the last path to the first, so VAULT_SECRET wins when keys
+	// overlap, followed by the optional secrets in the order they are listed.
+	secrets := map[string]string{}
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		if !r.OK() {
+			log.Warn("Skipping optional secret ", r.Path, ": ", r.Err)
+			continue
+		}
+
+		for k, v := range r.Data {
+			secrets[k] = v
+		}
+	}
 
-	writeStringToFile("/usr/share/vault/data/secrets", GenerateDotEnv(DotEnvVariables{Secrets: env[vaultSecret]}))
+	writeStringToFile(dataDir+"/secrets", GenerateDotEnv(DotEnvVariables{Secrets: secrets}))
 }
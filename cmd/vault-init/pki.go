@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/teamsnap/vault-key/pkg/vault"
+)
+
+// writeCertificate issues a certificate from the PKI role in VAULT_PKI_ROLE and
+// writes it, its private key and CA chain to the shared volume. It does
+// nothing when VAULT_PKI_ROLE is not set.
+func writeCertificate(ctx context.Context, client *vault.Client) {
+	role := os.Getenv("VAULT_PKI_ROLE")
+	if role == "" {
+		return
+	}
+
+	var ttl time.Duration
+	if s := os.Getenv("VAULT_PKI_TTL"); s != "" {
+		var err error
+		if ttl, err = time.ParseDuration(s); err != nil {
+			log.Fatal("Invalid VAULT_PKI_TTL: ", err)
+		}
+	}
+
+	cert, err := client.IssueCertificate(ctx, os.Getenv("VAULT_PKI_MOUNT"), role, vault.CertificateRequest{
+		CommonName: os.Getenv("VAULT_PKI_COMMON_NAME"),
+		AltNames:   envList("VAULT_PKI_ALT_NAMES"),
+		TTL:        ttl,
+	})
+	if err != nil {
+		log.Fatal("Error issuing certificate from Vault: ", err)
+	}
+
+	if err := cert.WriteFiles(dataDir+"/tls.crt", dataDir+"/tls.key", dataDir+"/ca.crt"); err != nil {
+		log.Fatal("Error writing certificate: ", err)
+	}
+
+	log.Info("Wrote certificate ", cert.SerialNumber, " expiring at ", cert.Expiration)
+}
@@ -8,9 +8,18 @@ import (
 	"go.uber.org/zap"
 )
 
+// conflict modes for keys found in more than one secret
+const (
+	conflictError    = "error"
+	conflictLastWins = "last-wins"
+)
+
 type config struct {
 	engine            string
 	defaultSecretPath string
+	secretPaths       []string
+	secretPrefix      string
+	conflictMode      string
 	k8sNamespace      string
 	k8sSecretName     string
 }
@@ -19,12 +28,15 @@ func newConfig(lgr *zap.Logger) *config {
 
 	// load required config from environment
 	defaultSecret := getEnv("VAULT_SECRET", "")
+	secretPaths := splitList(getEnv("VAULT_SECRETS", ""))
+	secretPrefix := getEnv("VAULT_SECRET_PREFIX", "")
+	conflictMode := getEnv("VAULT_SECRET_CONFLICT", conflictError)
 	k8sNamespace := getEnv("K8S_NAMESPACE", "")
 	k8sSecretName := getEnv("K8S_SECRET_NAME", k8s.DefaultSecretName)
 
 	req := []string{}
-	if len(defaultSecret) < 1 {
-		req = append(req, "VAULT_SECRET")
+	if len(defaultSecret) < 1 && len(secretPaths) < 1 && len(secretPrefix) < 1 {
+		req = append(req, "VAULT_SECRET, VAULT_SECRETS or VAULT_SECRET_PREFIX")
 	}
 	if len(k8sNamespace) < 1 {
 		req = append(req, "K8S_NAMESPACE")
@@ -32,18 +44,39 @@ func newConfig(lgr *zap.Logger) *config {
 	if len(req) > 0 {
 		lgr.Fatal("bad configuration", zap.Strings("missing environment variables", req))
 	}
+	if conflictMode != conflictError && conflictMode != conflictLastWins {
+		lgr.Fatal("bad configuration", zap.String("VAULT_SECRET_CONFLICT", conflictMode), zap.Strings("allowed values", []string{conflictError, conflictLastWins}))
+	}
 	lgr.Debug("vault-path to the default engine", zap.String("VAULT_SECRET", defaultSecret))
+	lgr.Debug("vault-paths to merge", zap.Strings("VAULT_SECRETS", secretPaths))
+	lgr.Debug("vault-path to list secrets to merge from", zap.String("VAULT_SECRET_PREFIX", secretPrefix))
+	lgr.Debug("handling of keys found in more than one secret", zap.String("VAULT_SECRET_CONFLICT", conflictMode))
 	lgr.Debug("kubernetes namespace to apply secret", zap.String("K8S_NAMESPACE", k8sNamespace))
 	lgr.Debug("kubernetes secret name", zap.String("K8S_SECRET_NAME", k8sSecretName))
 
 	return &config{
 		engine:            translatePath(defaultSecret),
 		defaultSecretPath: defaultSecret,
+		secretPaths:       secretPaths,
+		secretPrefix:      secretPrefix,
+		conflictMode:      conflictMode,
 		k8sNamespace:      k8sNamespace,
 		k8sSecretName:     k8sSecretName,
 	}
 }
 
+// splitList splits a comma separated list, dropping empty entries
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
 func getEnv(varName, defaultVal string) string {
 	if value, isPresent := os.LookupEnv(varName); isPresent {
 		return value
@@ -67,6 +100,25 @@ func translatePath(path string) string {
 	return strings.Join(strs[:len(strs)-1], "/")
 }
 
+// dataPath is a helper that converts a metadata path listed with the vault
+// api engines list function and one of its keys to the path of the secret.
+// Only the first metadata segment, the one of the engine, is replaced, so
+// folders named metadata are kept.
+//
+// ie staging/applications/metadata/foo, dotenv -> staging/applications/data/foo/dotenv
+func dataPath(prefix, key string) string {
+	strs := strings.Split(strings.TrimSuffix(prefix, "/"), "/")
+
+	for i, s := range strs {
+		if s == "metadata" {
+			strs[i] = "data"
+			break
+		}
+	}
+
+	return strings.Join(append(strs, key), "/")
+}
+
 // getSecret is a helper that takes a path to a vault secret
 // and returns the secret
 //
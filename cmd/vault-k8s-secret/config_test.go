@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDataPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		key      string
+		expected string
+	}{
+		{"metadata prefix", "staging/applications/metadata/foo", "dotenv", "staging/applications/data/foo/dotenv"},
+		{"trailing slash", "staging/applications/metadata/foo/", "dotenv", "staging/applications/data/foo/dotenv"},
+		{"engine root", "kv/metadata", "dotenv", "kv/data/dotenv"},
+		{"folder named metadata", "kv/metadata/app/metadata", "dotenv", "kv/data/app/metadata/dotenv"},
+		{"no metadata segment", "kv/app", "dotenv", "kv/app/dotenv"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(dataPath(tc.prefix, tc.key), tc.expected)
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"kv/data/a", []string{"kv/data/a"}},
+		{"kv/data/a, kv/data/b,,", []string{"kv/data/a", "kv/data/b"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(splitList(tc.input), tc.expected)
+		})
+	}
+}
@@ -43,17 +43,36 @@ func run(ctx context.Context, lgr *zap.Logger) error {
 
 	cfg := newConfig(lgr)
 
-	secretsToApply := []string{cfg.defaultSecretPath}
+	// one client, so vault is logged in to once for the listing and the reads
+	client, err := vault.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot create vault client: %w", err)
+	}
+	defer client.Close()
+
+	secretsToApply, err := secretPaths(ctx, client, cfg)
+	if err != nil {
+		return err
+	}
+	if len(secretsToApply) < 1 {
+		return fmt.Errorf("no secrets found under %s", cfg.secretPrefix)
+	}
+
 	lgr.Info("getting vault secrets from verified secret paths", zap.Strings("verified-secret-paths", secretsToApply))
 	secrets := map[string]map[string]string{}
-	if err := vault.GetSecrets(ctx, &secrets, secretsToApply); err != nil {
+	if err := client.GetSecrets(ctx, &secrets, secretsToApply); err != nil {
 		return fmt.Errorf("cannot get secrets from vault: %w", err)
 	}
 
-	lgr.Info("applying merged secrets to namespace", zap.String("namespace", cfg.k8sNamespace), zap.Int("number of secrets", len(secrets[cfg.defaultSecretPath])))
+	merged, err := mergeSecrets(lgr, secretsToApply, secrets, cfg.conflictMode == conflictLastWins)
+	if err != nil {
+		return err
+	}
+
+	lgr.Info("applying merged secrets to namespace", zap.String("namespace", cfg.k8sNamespace), zap.Int("number of secrets", len(merged)))
 	if err := k8s.ApplySecret(
 		&k8s.Secret{
-			Secrets:   secrets[cfg.defaultSecretPath],
+			Secrets:   merged,
 			Namespace: cfg.k8sNamespace,
 			Name:      cfg.k8sSecretName,
 		}); err != nil {
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// engineLister lists the keys under a vault path, like vault.Client.
+type engineLister interface {
+	ListEngines(ctx context.Context, path string) ([]string, error)
+}
+
+// secretPaths returns the vault paths to merge, in order of precedence from
+// lowest to highest: the secrets found under VAULT_SECRET_PREFIX in
+// alphabetical order, then VAULT_SECRET, then VAULT_SECRETS in the order they
+// are listed. Paths given more than once are only merged once, at their first
+// position.
+func secretPaths(ctx context.Context, client engineLister, cfg *config) ([]string, error) {
+	paths := []string{}
+
+	if len(cfg.secretPrefix) > 0 {
+		keys, err := client.ListEngines(ctx, cfg.secretPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list secrets under %s: %w", cfg.secretPrefix, err)
+		}
+
+		sort.Strings(keys)
+		for _, key := range keys {
+			// keys ending in a slash are folders, not secrets
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+
+			paths = append(paths, dataPath(cfg.secretPrefix, key))
+		}
+	}
+
+	if len(cfg.defaultSecretPath) > 0 {
+		paths = append(paths, cfg.defaultSecretPath)
+	}
+	paths = append(paths, cfg.secretPaths...)
+
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+
+		seen[path] = true
+		unique = append(unique, path)
+	}
+
+	return unique, nil
+}
+
+// mergeSecrets merges the keys of the secrets at paths into one map, in the
+// order of paths. A key found in more than one secret with different values
+// is a conflict: it fails the merge, listing every conflicting key, unless
+// lastWins is set, in which case the value of the later path is kept.
+func mergeSecrets(lgr *zap.Logger, paths []string, secrets map[string]map[string]string, lastWins bool) (map[string]string, error) {
+	merged := map[string]string{}
+	source := map[string]string{}
+	conflicts := []string{}
+
+	for _, path := range paths {
+		keys := make([]string, 0, len(secrets[path]))
+		for key := range secrets[path] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := secrets[path][key]
+			if previous, ok := merged[key]; ok && previous != value {
+				if !lastWins {
+					conflicts = append(conflicts, fmt.Sprintf("%s (%s, %s)", key, source[key], path))
+					continue
+				}
+
+				lgr.Warn("overriding conflicting key", zap.String("key", key), zap.String("overridden-path", source[key]), zap.String("path", path))
+			}
+
+			merged[key] = value
+			source[key] = path
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("conflicting keys in secrets, set VAULT_SECRET_CONFLICT=%s to keep the last value: %s", conflictLastWins, strings.Join(conflicts, ", "))
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"go.uber.org/zap"
+)
+
+// fakeLister lists the keys of the paths it holds, and fails for any other.
+type fakeLister map[string][]string
+
+func (f fakeLister) ListEngines(_ context.Context, path string) ([]string, error) {
+	keys, ok := f[path]
+	if !ok {
+		return nil, errors.New("permission denied")
+	}
+
+	return keys, nil
+}
+
+func TestSecretPaths(t *testing.T) {
+	lister := fakeLister{
+		"kv/metadata/app/":     {"shared", "folder/", "base"},
+		"kv/metadata/metadata": {"dotenv"},
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *config
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "single secret",
+			cfg:      &config{defaultSecretPath: "kv/data/app/dotenv"},
+			expected: []string{"kv/data/app/dotenv"},
+		}, {
+			name:     "secrets in order",
+			cfg:      &config{defaultSecretPath: "kv/data/a", secretPaths: []string{"kv/data/b", "kv/data/c"}},
+			expected: []string{"kv/data/a", "kv/data/b", "kv/data/c"},
+		}, {
+			name:     "prefix first and sorted, without folders",
+			cfg:      &config{secretPrefix: "kv/metadata/app/", defaultSecretPath: "kv/data/other"},
+			expected: []string{"kv/data/app/base", "kv/data/app/shared", "kv/data/other"},
+		}, {
+			name:     "folder named metadata",
+			cfg:      &config{secretPrefix: "kv/metadata/metadata"},
+			expected: []string{"kv/data/metadata/dotenv"},
+		}, {
+			name:     "duplicates kept at their first position",
+			cfg:      &config{secretPrefix: "kv/metadata/app/", secretPaths: []string{"kv/data/extra", "kv/data/app/base"}},
+			expected: []string{"kv/data/app/base", "kv/data/app/shared", "kv/data/extra"},
+		}, {
+			name: "list fails",
+			cfg:  &config{secretPrefix: "kv/metadata/forbidden"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			paths, err := secretPaths(context.Background(), lister, tc.cfg)
+			is.Equal(err != nil, tc.err)
+			is.Equal(paths, tc.expected)
+		})
+	}
+}
+
+func TestMergeSecrets(t *testing.T) {
+	secrets := map[string]map[string]string{
+		"kv/data/base":     {"DB_HOST": "db", "LOG_LEVEL": "info"},
+		"kv/data/app":      {"API_KEY": "key", "LOG_LEVEL": "debug"},
+		"kv/data/same":     {"DB_HOST": "db"},
+		"kv/data/conflict": {"API_KEY": "other", "DB_HOST": "other-db"},
+	}
+
+	tests := []struct {
+		name      string
+		paths     []string
+		lastWins  bool
+		expected  map[string]string
+		conflicts []string
+	}{
+		{
+			name:     "disjoint keys",
+			paths:    []string{"kv/data/same", "kv/data/app"},
+			expected: map[string]string{"DB_HOST": "db", "API_KEY": "key", "LOG_LEVEL": "debug"},
+		}, {
+			name:     "same values are not conflicts",
+			paths:    []string{"kv/data/base", "kv/data/same"},
+			expected: map[string]string{"DB_HOST": "db", "LOG_LEVEL": "info"},
+		}, {
+			name:      "conflicts fail",
+			paths:     []string{"kv/data/base", "kv/data/app", "kv/data/conflict"},
+			conflicts: []string{"LOG_LEVEL (kv/data/base, kv/data/app)", "API_KEY (kv/data/app, kv/data/conflict)", "DB_HOST (kv/data/base, kv/data/conflict)"},
+		}, {
+			name:     "last wins",
+			paths:    []string{"kv/data/base", "kv/data/app", "kv/data/conflict"},
+			lastWins: true,
+			expected: map[string]string{"DB_HOST": "other-db", "API_KEY": "other", "LOG_LEVEL": "debug"},
+		}, {
+			name:     "missing secret",
+			paths:    []string{"kv/data/missing", "kv/data/same"},
+			expected: map[string]string{"DB_HOST": "db"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			merged, err := mergeSecrets(zap.NewNop(), tc.paths, secrets, tc.lastWins)
+			if len(tc.conflicts) > 0 {
+				is.True(err != nil)
+				for _, conflict := range tc.conflicts {
+					is.True(strings.Contains(err.Error(), conflict))
+				}
+				return
+			}
+
+			is.NoErr(err)
+			is.Equal(merged, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/teamsnap/vault-key/pkg/vault"
+)
+
+const usage = `Usage: vault-kv <command> [-versions 1,2,3] <secret-path>
+
+Commands:
+  delete           soft delete the latest version, or the given versions
+  undelete         restore soft deleted versions
+  destroy          permanently remove the data of versions
+  delete-metadata  permanently remove the secret with all versions and metadata
+
+Vault is configured from the same environment variables as pkg/vault.
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "vault-kv:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	cmd, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return cmd.run(ctx, client)
+}
+
+// kvClient is the part of vault.Client the commands use.
+type kvClient interface {
+	DeleteSecretVersions(ctx context.Context, secretName string, versions ...int64) error
+	UndeleteSecretVersions(ctx context.Context, secretName string, versions ...int64) error
+	DestroySecretVersions(ctx context.Context, secretName string, versions ...int64) error
+	DeleteSecretMetadata(ctx context.Context, secretName string) error
+	Close()
+}
+
+// newClient returns the client the commands run against. Tests replace it.
+var newClient = func(ctx context.Context) (kvClient, error) {
+	client, err := vault.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// command is a parsed command line.
+type command struct {
+	name     string
+	path     string
+	versions []int64
+}
+
+// parseArgs parses the command, its flags and the secret path from args.
+func parseArgs(args []string) (*command, error) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return nil, fmt.Errorf("missing command")
+	}
+
+	name := args[0]
+	switch name {
+	case "delete", "undelete", "destroy", "delete-metadata":
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return nil, fmt.Errorf("unknown command %q", name)
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	versionList := flags.String("versions", "", "comma separated list of secret versions")
+	if err := flags.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return nil, fmt.Errorf("%s needs exactly one secret path", name)
+	}
+
+	versions, err := parseVersions(*versionList)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "delete-metadata" && len(versions) > 0 {
+		return nil, fmt.Errorf("delete-metadata removes every version, -versions can't be used")
+	}
+
+	return &command{name: name, path: flags.Arg(0), versions: versions}, nil
+}
+
+// run runs the command with client.
+func (c *command) run(ctx context.Context, client kvClient) error {
+	switch c.name {
+	case "delete":
+		return client.DeleteSecretVersions(ctx, c.path, c.versions...)
+	case "undelete":
+		return client.UndeleteSecretVersions(ctx, c.path, c.versions...)
+	case "destroy":
+		return client.DestroySecretVersions(ctx, c.path, c.versions...)
+	default:
+		return client.DeleteSecretMetadata(ctx, c.path)
+	}
+}
+
+// parseVersions parses a comma separated list of versions, like "1,2,3".
+func parseVersions(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var versions []int64
+	for _, v := range strings.Split(s, ",") {
+		version, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected *command
+		err      bool
+	}{
+		{"delete latest", []string{"delete", "kv/foo"}, &command{name: "delete", path: "kv/foo"}, false},
+		{"delete versions", []string{"delete", "-versions", "1,2", "kv/foo"}, &command{name: "delete", path: "kv/foo", versions: []int64{1, 2}}, false},
+		{"undelete", []string{"undelete", "-versions=3", "kv/foo"}, &command{name: "undelete", path: "kv/foo", versions: []int64{3}}, false},
+		{"destroy", []string{"destroy", "-versions", "1, 4", "kv/foo"}, &command{name: "destroy", path: "kv/foo", versions: []int64{1, 4}}, false},
+		{"delete metadata", []string{"delete-metadata", "kv/foo"}, &command{name: "delete-metadata", path: "kv/foo"}, false},
+		{"no command", nil, nil, true},
+		{"unknown command", []string{"purge", "kv/foo"}, nil, true},
+		{"no path", []string{"delete"}, nil, true},
+		{"two paths", []string{"delete", "kv/foo", "kv/bar"}, nil, true},
+		{"flag after path", []string{"delete", "kv/foo", "-versions", "1"}, nil, true},
+		{"unknown flag", []string{"delete", "-force", "kv/foo"}, nil, true},
+		{"invalid version", []string{"destroy", "-versions", "1,x", "kv/foo"}, nil, true},
+		{"metadata with versions", []string{"delete-metadata", "-versions", "1", "kv/foo"}, nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			cmd, err := parseArgs(tc.args)
+			is.Equal(err != nil, tc.err)
+			is.Equal(cmd, tc.expected)
+		})
+	}
+}
+
+func TestParseVersions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int64
+		err      bool
+	}{
+		{"", nil, false},
+		{"1", []int64{1}, false},
+		{"1,2, 3", []int64{1, 2, 3}, false},
+		{"0", nil, true},
+		{"-1", nil, true},
+		{"1,,2", nil, true},
+		{"one", nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			is := is.New(t)
+
+			versions, err := parseVersions(tc.input)
+			is.Equal(err != nil, tc.err)
+			is.Equal(versions, tc.expected)
+		})
+	}
+}
+
+// fakeClient records the calls of the commands.
+type fakeClient struct {
+	calls  []string
+	path   string
+	vers   []int64
+	closed bool
+	err    error
+}
+
+func (f *fakeClient) record(call, path string, versions []int64) error {
+	f.calls = append(f.calls, call)
+	f.path, f.vers = path, versions
+
+	return f.err
+}
+
+func (f *fakeClient) DeleteSecretVersions(_ context.Context, path string, versions ...int64) error {
+	return f.record("delete", path, versions)
+}
+
+func (f *fakeClient) UndeleteSecretVersions(_ context.Context, path string, versions ...int64) error {
+	return f.record("undelete", path, versions)
+}
+
+func (f *fakeClient) DestroySecretVersions(_ context.Context, path string, versions ...int64) error {
+	return f.record("destroy", path, versions)
+}
+
+func (f *fakeClient) DeleteSecretMetadata(_ context.Context, path string) error {
+	return f.record("delete-metadata", path, nil)
+}
+
+func (f *fakeClient) Close() {
+	f.closed = true
+}
+
+func TestRun(t *testing.T) {
+	fake := &fakeClient{}
+	defer func(f func(context.Context) (kvClient, error)) { newClient = f }(newClient)
+	newClient = func(context.Context) (kvClient, error) { return fake, nil }
+
+	tests := []struct {
+		args     []string
+		call     string
+		versions []int64
+	}{
+		{[]string{"delete", "kv/foo"}, "delete", nil},
+		{[]string{"delete", "-versions", "2", "kv/foo"}, "delete", []int64{2}},
+		{[]string{"undelete", "-versions", "1,2", "kv/foo"}, "undelete", []int64{1, 2}},
+		{[]string{"destroy", "-versions", "3", "kv/foo"}, "destroy", []int64{3}},
+		{[]string{"delete-metadata", "kv/foo"}, "delete-metadata", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.call, func(t *testing.T) {
+			is := is.New(t)
+			*fake = fakeClient{}
+
+			is.NoErr(run(context.Background(), tc.args))
+			is.Equal(fake.calls, []string{tc.call})
+			is.Equal(fake.path, "kv/foo")
+			is.Equal(fake.vers, tc.versions)
+			is.True(fake.closed)
+		})
+	}
+
+	t.Run("client error", func(t *testing.T) {
+		is := is.New(t)
+		*fake = fakeClient{err: errors.New("permission denied")}
+
+		is.Equal(run(context.Background(), []string{"destroy", "-versions", "1", "kv/foo"}), fake.err)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		is := is.New(t)
+		*fake = fakeClient{}
+
+		is.True(run(context.Background(), []string{"destroy"}) != nil)
+		is.Equal(len(fake.calls), 0)
+	})
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"log"
 
-	"contrib.go.opencensus.io/exporter/stackdriver"
+	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"github.com/teamsnap/vault-key/pkg/vault"
-	"go.opencensus.io/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 var env = map[string]map[string]string{}
@@ -22,16 +22,18 @@ type PubSubMessage struct {
 	Data []byte `json:"data"`
 }
 
-func createTraceExporter() {
-	// Create and register a OpenCensus Stackdriver Trace exporter.
-	exporter, err := stackdriver.NewExporter(stackdriver.Options{})
+func createTracerProvider() *sdktrace.TracerProvider {
+	// Create an OpenTelemetry Cloud Trace exporter.
+	exporter, err := texporter.New()
 	if err != nil {
 		log.Fatal(err)
 	}
-	trace.RegisterExporter(exporter)
 
 	// necessary or it won't take enough samples for us to notice
-	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
 }
 
 // init runs during package initialization. this will only run during an
@@ -39,9 +41,19 @@ func createTraceExporter() {
 func init() {
 	ctx := context.Background()
 
-	createTraceExporter()
+	tp := createTracerProvider()
+	// the instance may be frozen once init returns, so send the spans now
+	defer tp.ForceFlush(ctx)
 
-	vault.GetSecrets(ctx, &env, envMap)
+	client, err := vault.NewClient(ctx, vault.WithTracerProvider(tp))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.GetSecrets(ctx, &env, envMap); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func vaultOnInit(ctx context.Context, m PubSubMessage) error {
@@ -0,0 +1,115 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type appRoleAuthClient struct {
+}
+
+// NewAppRoleAuthClient returns a new instance of an auth client
+func NewAppRoleAuthClient() AuthClient {
+	return &appRoleAuthClient{}
+}
+
+func (a *appRoleAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/approle/GetVaultToken", vc.config.tracePrefix))()
+
+	vaultResp, err := a.login(vc)
+	if err != nil {
+		return "", err
+	}
+
+	return vaultResp.Auth.ClientToken, nil
+}
+
+func (a *appRoleAuthClient) login(vc *vaultClient) (*api.Secret, error) {
+	vaultResp, err := a.appRoleVaultAuth(vc)
+	if err != nil {
+		return nil, &AuthError{Method: "approle", Err: err}
+	}
+
+	return vaultResp, nil
+}
+
+// appRoleVaultAuth takes the role and secret IDs and sends login request to vault
+func (a *appRoleAuthClient) appRoleVaultAuth(vc *vaultClient) (*api.Secret, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/approle/vaultLogin", vc.config.tracePrefix))()
+
+	c := vc.config.approle
+
+	roleID, err := valueOrFile(c.roleID, c.roleIDFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading approle role id: %w", err)
+	}
+
+	secretID, err := valueOrFile(c.secretID, c.secretIDFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading approle secret id: %w", err)
+	}
+
+	if c.wrapped {
+		secretID, err = a.unwrapSecretID(vc, secretID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := map[string]interface{}{
+		"role_id": roleID,
+	}
+	if secretID != "" {
+		data["secret_id"] = secretID
+	}
+
+	vaultResp, err := vc.client.Logical().WriteWithContext(vc.ctx, "auth/"+c.authPath+"/login", data)
+	if err != nil {
+		return nil, fmt.Errorf("logging into vault with approle:%w", err)
+	}
+
+	if vaultResp == nil || vaultResp.Auth == nil {
+		return nil, fmt.Errorf("logging into vault with approle: no auth info returned")
+	}
+
+	return vaultResp, nil
+}
+
+// unwrapSecretID exchanges a response-wrapping token for the secret ID it wraps.
+// A wrapping token can only be unwrapped once.
+func (a *appRoleAuthClient) unwrapSecretID(vc *vaultClient, wrappingToken string) (string, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/approle/unwrapSecretID", vc.config.tracePrefix))()
+
+	unwrapped, err := vc.client.Logical().UnwrapWithContext(vc.ctx, wrappingToken)
+	if err != nil {
+		return "", fmt.Errorf("unwrapping approle secret id: %w", err)
+	}
+
+	if unwrapped == nil || unwrapped.Data == nil {
+		return "", fmt.Errorf("unwrapping approle secret id: no data returned")
+	}
+
+	secretID, ok := unwrapped.Data["secret_id"].(string)
+	if !ok {
+		return "", fmt.Errorf("unwrapping approle secret id: secret_id missing from wrapped response")
+	}
+
+	return secretID, nil
+}
+
+// valueOrFile returns value, or the trimmed contents of path when value is empty.
+func valueOrFile(value, path string) (string, error) {
+	if value != "" || path == "" {
+		return value, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
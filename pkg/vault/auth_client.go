@@ -1,9 +1,11 @@
 package vault
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/vault/api"
 )
 
 // AuthClient is a type that satifies the necesary authorization layer for a vault client.
@@ -11,16 +13,98 @@ type AuthClient interface {
 	GetVaultToken(vc *vaultClient) (string, error)
 }
 
-func NewAuthClient(c *config) AuthClient {
+// loginClient is implemented by auth clients that return the full login
+// response, whose Auth carries the token lease used for renewal.
+type loginClient interface {
+	login(vc *vaultClient) (*api.Secret, error)
+}
+
+// NewAuthClient returns the AuthClient for the configured auth method, or
+// ErrNoAuthMethod if none is configured.
+func NewAuthClient(c *config) (AuthClient, error) {
 	switch {
+	case c.authClient != nil:
+		return c.authClient, nil
 	case len(c.githubToken) > 0:
-		return NewGithubAuthClient()
+		return NewGithubAuthClient(), nil
+	case len(c.k8sRole) > 0:
+		return NewKubernetesAuthClient(), nil
+	case c.approle.enabled():
+		return NewAppRoleAuthClient(), nil
+	case len(c.certAuthPath) > 0:
+		return NewCertAuthClient(), nil
 	case len(c.project) > 0:
-		return NewGcpAuthClient()
+		return NewGcpAuthClient(), nil
 	default:
-		log.Error("GetVaultToken: configuration error, one of [githubAuth, googleAuth] must be set to true")
-		os.Exit(1)
+		return nil, fmt.Errorf("%w: one of [githubAuth, kubernetesAuth, appRoleAuth, certAuth, googleAuth] must be set", ErrNoAuthMethod)
+	}
+}
+
+// logLogin logs the outcome of a login. It takes a pointer to the error so it
+// can be deferred.
+func (vc *vaultClient) logLogin(err *error) {
+	method := vc.config.authMethod()
+	if *err != nil {
+		vc.logger().Warn("vault login failed", "method", method, "error", *err)
+		return
+	}
+
+	vc.logger().Debug("logged in to vault", "method", method, "address", vc.client.Address())
+}
+
+// login authenticates with the configured AuthClient, sets the resulting
+// token on the api client and returns the login response. For auth clients
+// that only return a token, the lease is looked up from Vault.
+func (vc *vaultClient) login() (_ *api.Secret, err error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/login", vc.config.tracePrefix))()
+	defer vc.observe(opLogin, time.Now(), &err, authMethodKey.String(vc.config.authMethod()))
+	defer vc.logLogin(&err)
+
+	a, err := NewAuthClient(vc.config)
+	if err != nil {
+		return nil, err
+	}
+
+	if l, ok := a.(loginClient); ok {
+		secret, err := l.login(vc)
+		if err != nil {
+			return nil, err
+		}
+
+		vc.client.SetToken(secret.Auth.ClientToken)
+		return secret, nil
+	}
+
+	token, err := a.GetVaultToken(vc)
+	if err != nil {
+		if !errors.Is(err, ErrAuthFailed) {
+			err = &AuthError{Method: "token", Err: err}
+		}
+
+		return nil, err
+	}
+
+	vc.client.SetToken(token)
+	lookup, err := vc.client.Auth().Token().LookupSelfWithContext(vc.ctx)
+	if err != nil {
+		return nil, &AuthError{Method: "token", Err: fmt.Errorf("looking up token: %w", err)}
+	}
+
+	ttl, err := lookup.TokenTTL()
+	if err != nil {
+		return nil, fmt.Errorf("reading token ttl: %w", err)
+	}
+
+	renewable, err := lookup.TokenIsRenewable()
+	if err != nil {
+		return nil, fmt.Errorf("reading token renewability: %w", err)
 	}
 
-	return nil
+	return &api.Secret{
+		Auth: &api.SecretAuth{
+			ClientToken:   token,
+			LeaseDuration: int(ttl.Seconds()),
+			Renewable:     renewable,
+		},
+	}, nil
 }
@@ -2,15 +2,21 @@ package vault
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/go-jose/go-jose/v4"
+	josejwt "github.com/go-jose/go-jose/v4/jwt"
 	"github.com/hashicorp/vault/api"
+	hashivault "github.com/hashicorp/vault/vault"
 	"github.com/matryer/is"
 )
 
@@ -108,3 +114,259 @@ func vaultLoginServer() *httptest.Server {
 		}
 	}))
 }
+
+func TestKubernetesConfig(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("GITHUB_OAUTH_TOKEN", "")
+	t.Setenv("K8S_AUTH_ROLE", "app")
+
+	cfg, err := loadVaultEnvironment()
+	is.NoErr(err)
+
+	is.Equal(cfg.k8sRole, "app")
+	is.Equal(cfg.k8sAuthPath, "kubernetes")
+	is.Equal(cfg.k8sTokenPath, defaultK8sTokenPath)
+
+	a, err := NewAuthClient(cfg)
+	is.NoErr(err)
+	_, ok := a.(*kubernetesAuthClient)
+	is.True(ok)
+}
+
+func TestKubernetesVaultClient(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/kubernetes/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	reviewServer := tokenReviewServer("default", "vault-auth", "1234")
+	defer reviewServer.Close()
+
+	root := cluster.Cores[0].Client
+	if err := root.Sys().EnableAuthWithOptions("kubernetes", &api.EnableAuthOptions{Type: "kubernetes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := root.Sys().PutPolicy("kv-read", `path "kv/*" { capabilities = ["read"] }`); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, data := range map[string]map[string]interface{}{
+		"auth/kubernetes/config": {
+			"kubernetes_host":      reviewServer.URL,
+			"disable_local_ca_jwt": true,
+		},
+		"auth/kubernetes/role/app": {
+			"bound_service_account_names":      "vault-auth",
+			"bound_service_account_namespaces": "default",
+			"token_policies":                   "kv-read",
+		},
+	} {
+		if _, err := root.Logical().Write(path, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte(serviceAccountJWT(t, "default", "vault-auth", "1234")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid service account", testKubernetesLogin(cluster, tokenPath, "app", false))
+	t.Run("token reference", testKubernetesLogin(cluster, "file://"+tokenPath, "app", false))
+	t.Run("unknown role", testKubernetesLogin(cluster, tokenPath, "missing", true))
+}
+
+func testKubernetesLogin(cluster *hashivault.TestCluster, tokenPath, role string, fails bool) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		c, err := NewClient(context.Background(),
+			WithAddress(cluster.Cores[0].Client.Address()),
+			WithTLSConfig(&api.TLSConfig{CACertBytes: cluster.CACertPEM}),
+			WithKubernetesAuth("", role, tokenPath),
+		)
+		if fails {
+			// the login is rejected, not the connection
+			is.True(errors.Is(err, ErrAuthFailed))
+
+			var authErr *AuthError
+			is.True(errors.As(err, &authErr))
+			is.Equal(authErr.Method, "kubernetes")
+
+			var respErr *api.ResponseError
+			is.True(errors.As(err, &respErr))
+			is.Equal(respErr.StatusCode, http.StatusBadRequest)
+			return
+		}
+		is.NoErr(err)
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine][secretKey], secretValue)
+	}
+}
+
+// serviceAccountJWT returns a kubernetes service account token for the given
+// account. Vault doesn't verify its signature unless configured with keys.
+func serviceAccountJWT(t *testing.T, namespace, name, uid string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := josejwt.Signed(signer).Claims(map[string]interface{}{
+		"iss":                                    "kubernetes/serviceaccount",
+		"kubernetes.io/serviceaccount/namespace": namespace,
+		"kubernetes.io/serviceaccount/service-account.name": name,
+		"kubernetes.io/serviceaccount/service-account.uid":  uid,
+	}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+// tokenReviewServer answers kubernetes TokenReview requests, authenticating
+// every token as the given service account.
+func tokenReviewServer(namespace, name, uid string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kind":       "TokenReview",
+			"apiVersion": "authentication.k8s.io/v1",
+			"status": map[string]interface{}{
+				"authenticated": true,
+				"user": map[string]interface{}{
+					"username": "system:serviceaccount:" + namespace + ":" + name,
+					"uid":      uid,
+				},
+			},
+		})
+	}))
+}
+
+func TestAppRoleConfig(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("GITHUB_OAUTH_TOKEN", "")
+	t.Setenv("APPROLE_ROLE_ID_FILE", "/etc/vault/role-id")
+	t.Setenv("APPROLE_SECRET_ID_FILE", "/etc/vault/secret-id")
+	t.Setenv("APPROLE_SECRET_ID_WRAPPED", "true")
+
+	cfg, err := loadVaultEnvironment()
+	is.NoErr(err)
+
+	is.Equal(cfg.approle, appRoleConfig{
+		authPath:     "approle",
+		roleIDFile:   "/etc/vault/role-id",
+		secretIDFile: "/etc/vault/secret-id",
+		wrapped:      true,
+	})
+
+	a, err := NewAuthClient(cfg)
+	is.NoErr(err)
+	_, ok := a.(*appRoleAuthClient)
+	is.True(ok)
+}
+
+func TestAppRoleVaultClient(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/approle/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	root := cluster.Cores[0].Client
+	if err := root.Sys().EnableAuthWithOptions("approle", &api.EnableAuthOptions{Type: "approle"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := root.Sys().PutPolicy("kv-read", `path "kv/*" { capabilities = ["read"] }`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := root.Logical().Write("auth/approle/role/ci", map[string]interface{}{
+		"token_policies": "kv-read",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	roleIDResp, err := root.Logical().Read("auth/approle/role/ci/role-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleID := roleIDResp.Data["role_id"].(string)
+
+	secretID := func(wrapped bool) string {
+		c, err := root.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetToken(root.Token())
+		if wrapped {
+			c.SetWrappingLookupFunc(func(operation, path string) string { return "60s" })
+		}
+
+		resp, err := c.Logical().Write("auth/approle/role/ci/secret-id", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wrapped {
+			return resp.WrapInfo.Token
+		}
+
+		return resp.Data["secret_id"].(string)
+	}
+
+	dir := t.TempDir()
+	roleIDFile, secretIDFile := filepath.Join(dir, "role-id"), filepath.Join(dir, "secret-id")
+	if err := os.WriteFile(roleIDFile, []byte(roleID+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(secretIDFile, []byte(secretID(false)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("role and secret id", testAppRoleLogin(cluster, WithAppRoleAuth("", roleID, secretID(false))))
+	t.Run("role and secret id files", testAppRoleLogin(cluster, WithAppRoleAuthFiles("", roleIDFile, secretIDFile)))
+	t.Run("role and secret id file references", testAppRoleLogin(cluster, WithAppRoleAuthFiles("", "file://"+roleIDFile, "file://"+secretIDFile)))
+	t.Run("wrapped secret id", testAppRoleLogin(cluster, WithAppRoleAuth("", roleID, secretID(true)), WithWrappedSecretID()))
+	t.Run("invalid secret id", testAppRoleLoginFails(cluster, WithAppRoleAuth("", roleID, "not-a-secret-id")))
+}
+
+func testAppRoleLogin(cluster *hashivault.TestCluster, opts ...Option) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		opts = append(opts,
+			WithAddress(cluster.Cores[0].Client.Address()),
+			WithTLSConfig(&api.TLSConfig{CACertBytes: cluster.CACertPEM}),
+		)
+		c, err := NewClient(context.Background(), opts...)
+		is.NoErr(err)
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine][secretKey], secretValue)
+	}
+}
+
+func testAppRoleLoginFails(cluster *hashivault.TestCluster, opts ...Option) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		opts = append(opts,
+			WithAddress(cluster.Cores[0].Client.Address()),
+			WithTLSConfig(&api.TLSConfig{CACertBytes: cluster.CACertPEM}),
+		)
+		_, err := NewClient(context.Background(), opts...)
+		is.True(err != nil)
+	}
+}
@@ -0,0 +1,68 @@
+package vault
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// defaultConcurrency is the number of secrets read at the same time when
+// WithConcurrency is not set.
+const defaultConcurrency = 4
+
+// secretResult is the outcome of reading one secret of a batch.
+type secretResult struct {
+	data    map[string]string
+	version int64
+	err     error
+	// skipped is set when the secret was not read because another read of
+	// the batch had already failed.
+	skipped bool
+}
+
+// fetchSecrets reads secrets with a bounded number of workers and returns
+// their results in the order of secretNames. When failFast is set, no new
+// reads are started once one has failed.
+func (vc *vaultClient) fetchSecrets(secretNames []string, failFast bool) []secretResult {
+	results := make([]secretResult, len(secretNames))
+
+	workers := vc.config.concurrency
+	if workers < 1 {
+		workers = defaultConcurrency
+	}
+	workers = min(workers, len(secretNames))
+
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Tracing updates the client's context, so each worker gets its own copy.
+			wvc := vc.withContext(vc.ctx)
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(secretNames) {
+					return
+				}
+
+				if failFast && failed.Load() {
+					results[i].skipped = true
+					continue
+				}
+
+				r := &results[i]
+				r.data, r.version, r.err = wvc.secretStrings(secretNames[i])
+				if r.err != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,114 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestBulkSecrets(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/bulk/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+
+	var paths []string
+	for i := range 20 {
+		path := fmt.Sprintf("kv/data/bulk/secret-%d", i)
+		_, err := rootVaultClient.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{"index": fmt.Sprint(i)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	t.Run("concurrent reads", testBulkRead(newTestClient(t, cluster, WithConcurrency(8)), paths))
+	t.Run("first error", testBulkFirstError(newTestClient(t, cluster, WithConcurrency(8)), paths))
+	t.Run("collect errors", testBulkCollectErrors(newTestClient(t, cluster, WithConcurrency(8), WithCollectErrors()), paths))
+	t.Run("skips after first error", testBulkSkipped(newTestClient(t, cluster, WithConcurrency(1)), paths))
+}
+
+func testBulkRead(c *Client, paths []string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, paths))
+
+		is.Equal(len(secrets), len(paths))
+		for i, path := range paths {
+			is.Equal(secrets[path]["index"], fmt.Sprint(i))
+		}
+	}
+}
+
+func testBulkFirstError(c *Client, paths []string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		names := append([]string{}, paths[:5]...)
+		names = append(names, "kv/data/bulk/missing-1", "kv/data/bulk/missing-2")
+
+		secrets := map[string]map[string]string{}
+		err := c.GetSecrets(context.Background(), &secrets, names)
+		is.True(errors.Is(err, ErrSecretNotFound))
+		is.True(strings.Contains(err.Error(), "missing-1"))
+
+		for _, path := range paths[:5] {
+			is.True(secrets[path] != nil)
+		}
+	}
+}
+
+func testBulkSkipped(c *Client, paths []string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		names := []string{paths[0], "kv/data/bulk/missing-1", paths[1], paths[2]}
+
+		results := c.vc.fetchSecrets(names, true)
+		is.NoErr(results[0].err)
+		is.True(errors.Is(results[1].err, ErrSecretNotFound))
+		for _, r := range results[2:] {
+			is.True(r.skipped)
+			is.NoErr(r.err)
+		}
+
+		// skipped secrets are not filled in
+		secrets := map[string]map[string]string{}
+		err := c.GetSecrets(context.Background(), &secrets, names)
+		is.True(errors.Is(err, ErrSecretNotFound))
+		is.Equal(len(secrets), 1)
+		is.True(secrets[paths[0]] != nil)
+	}
+}
+
+func testBulkCollectErrors(c *Client, paths []string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		names := append([]string{"kv/data/bulk/missing-1"}, paths...)
+		names = append(names, "kv/data/bulk/missing-2")
+
+		secrets := map[string]map[string]string{}
+		err := c.GetSecrets(context.Background(), &secrets, names)
+		is.True(errors.Is(err, ErrSecretNotFound))
+		is.True(strings.Contains(err.Error(), "missing-1"))
+		is.True(strings.Contains(err.Error(), "missing-2"))
+
+		var joined interface{ Unwrap() []error }
+		is.True(errors.As(err, &joined))
+		is.Equal(len(joined.Unwrap()), 2)
+
+		is.Equal(len(secrets), len(paths))
+	}
+}
@@ -0,0 +1,198 @@
+package vault
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheStats counts how secret reads were served by the cache.
+type CacheStats struct {
+	// Hits are reads served from the cache within the TTL.
+	Hits uint64
+	// Revalidations are reads of expired entries that were served from the
+	// cache because the secret's current version had not changed.
+	Revalidations uint64
+	// Misses are reads that fetched the secret data from Vault.
+	Misses uint64
+}
+
+// secretCache caches secret data by API path. It is shared by all copies of a
+// vaultClient and safe for concurrent use.
+type secretCache struct {
+	ttl        time.Duration
+	revalidate bool
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	stats   CacheStats
+}
+
+type cacheEntry struct {
+	data    map[string]interface{}
+	version int64
+	expires time.Time
+}
+
+func newSecretCache(ttl time.Duration, revalidate bool) *secretCache {
+	return &secretCache{
+		ttl:        ttl,
+		revalidate: revalidate,
+		now:        time.Now,
+		entries:    map[string]cacheEntry{},
+	}
+}
+
+// get returns the entry for key, whether it is still within the TTL, and
+// whether it was found at all.
+func (c *secretCache) get(key string) (cacheEntry, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false, false
+	}
+
+	return e, c.now().Before(e.expires), true
+}
+
+func (c *secretCache) put(key string, data map[string]interface{}, version int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{
+		data:    deepCopy(data),
+		version: version,
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+// refresh restarts the TTL of the entry for key.
+func (c *secretCache) refresh(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.expires = c.now().Add(c.ttl)
+		c.entries[key] = e
+	}
+}
+
+// invalidate removes the entries for keys, or every entry if no keys are given.
+func (c *secretCache) invalidate(keys ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(keys) == 0 {
+		clear(c.entries)
+		return
+	}
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+func (c *secretCache) count(stat *uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*stat++
+}
+
+func (c *secretCache) snapshot() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// cacheKey returns the key secretName is cached under, so that "kv/foo" and
+// "kv/data/foo" share an entry on KV v2. Keys are prefixed with the namespace
+// of the request, if any, so secrets of different namespaces don't collide.
+func (vc *vaultClient) cacheKey(secretName string) string {
+	key := vc.kvMount(secretName).apiPath(secretName, "data")
+	if namespace := vc.client.Namespace(); namespace != "" {
+		key = namespace + ":" + key
+	}
+
+	return key
+}
+
+// readCachedSecret reads the latest version of a secret like readSecret does,
+// serving it from the cache when one is configured. Once an entry expires, it
+// is served again without reading the data if revalidation is enabled and the
+// secret's current version is still the cached one.
+func (vc *vaultClient) readCachedSecret(secretName string) (map[string]interface{}, int64, error) {
+	c := vc.cache
+	if c == nil {
+		return vc.readSecret(secretName)
+	}
+
+	key := vc.cacheKey(secretName)
+	e, fresh, found := c.get(key)
+	if fresh {
+		c.count(&c.stats.Hits)
+		return deepCopy(e.data), e.version, nil
+	}
+
+	if found && c.revalidate && e.version > 0 {
+		if version, err := vc.SecretVersionFromVault(secretName); err == nil && version == e.version {
+			c.refresh(key)
+			c.count(&c.stats.Revalidations)
+			return deepCopy(e.data), e.version, nil
+		}
+	}
+
+	c.count(&c.stats.Misses)
+
+	data, version, err := vc.readSecret(secretName)
+	if err != nil {
+		c.invalidate(key)
+		return data, version, err
+	}
+
+	c.put(key, data, version)
+
+	return data, version, nil
+}
+
+// deepCopy copies data along with the maps and slices nested in its values, so
+// that callers can't change what the cache holds.
+func deepCopy(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	c := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		c[k] = deepCopyValue(v)
+	}
+
+	return c
+}
+
+func deepCopyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return deepCopy(v)
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = deepCopyValue(e)
+		}
+
+		return c
+	default:
+		return v
+	}
+}
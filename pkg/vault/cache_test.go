@@ -0,0 +1,163 @@
+package vault
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestSecretCache(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/cache/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	t.Run("hits and misses", testCacheHits(newTestClient(t, cluster, WithCache(time.Minute))))
+	t.Run("writes invalidate", testCacheWrites(newTestClient(t, cluster, WithCache(time.Minute))))
+	t.Run("revalidation", testCacheRevalidation(newTestClient(t, cluster, WithCache(time.Minute), WithCacheRevalidation()), rootVaultClient))
+	t.Run("concurrent reads", testCacheConcurrentReads(newTestClient(t, cluster, WithCache(time.Minute))))
+	t.Run("nested values are copied", testCacheNestedValues(newTestClient(t, cluster, WithCache(time.Minute)), rootVaultClient))
+	t.Run("disabled", testCacheDisabled(newTestClient(t, cluster)))
+}
+
+func testCacheHits(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		for range 3 {
+			secrets := map[string]map[string]string{}
+			is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+			is.Equal(secrets[secretEngine][secretKey], secretValue)
+		}
+
+		// The path without the data segment shares the cache entry.
+		data, err := c.GetSecretData(context.Background(), "kv/cache/foo")
+		is.NoErr(err)
+		is.Equal(data[secretKey], secretValue)
+
+		is.Equal(c.CacheStats(), CacheStats{Hits: 3, Misses: 1})
+
+		c.InvalidateCache(secretEngine)
+		_, err = c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(c.CacheStats().Misses, uint64(2))
+	}
+}
+
+func testCacheWrites(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+
+		// Changing the returned data must not change the cached data.
+		data[secretKey] = "changed"
+
+		data, err = c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data[secretKey], secretValue)
+
+		is.NoErr(c.CreateSecret(context.Background(), secretEngine, "newKey", "newValue"))
+
+		data, err = c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data["newKey"], "newValue")
+	}
+}
+
+func testCacheRevalidation(c *Client, root *api.Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		now := time.Now()
+		c.vc.cache.now = func() time.Time { return now }
+
+		_, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+
+		now = now.Add(2 * time.Minute)
+		_, err = c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(c.CacheStats(), CacheStats{Revalidations: 1, Misses: 1})
+
+		_, err = root.Logical().Write(secretEngine, map[string]interface{}{
+			"data": map[string]interface{}{secretKey: "rotated"},
+		})
+		is.NoErr(err)
+
+		// Still within the TTL, so the old value is served.
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data[secretKey], secretValue)
+
+		now = now.Add(2 * time.Minute)
+		data, err = c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data[secretKey], "rotated")
+		is.Equal(c.CacheStats(), CacheStats{Hits: 1, Revalidations: 1, Misses: 2})
+	}
+}
+
+func testCacheConcurrentReads(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := c.GetSecretData(context.Background(), secretEngine)
+				is.NoErr(err)
+			}()
+		}
+		wg.Wait()
+
+		stats := c.CacheStats()
+		is.Equal(stats.Hits+stats.Misses, uint64(20))
+	}
+}
+
+func testCacheNestedValues(c *Client, root *api.Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		path := "kv/data/cache/nested"
+		_, err := root.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{
+				"database": map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+			},
+		})
+		is.NoErr(err)
+
+		data, err := c.GetSecretData(context.Background(), path)
+		is.NoErr(err)
+
+		// Changing nested values must not change the cached data either.
+		database := data["database"].(map[string]interface{})
+		database["hosts"].([]interface{})[0] = "changed"
+		database["user"] = "changed"
+
+		data, err = c.GetSecretData(context.Background(), path)
+		is.NoErr(err)
+		is.Equal(data["database"], map[string]interface{}{"hosts": []interface{}{"a", "b"}})
+		is.Equal(c.CacheStats().Hits, uint64(1))
+	}
+}
+
+func testCacheDisabled(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(c.CacheStats(), CacheStats{})
+	}
+}
@@ -0,0 +1,58 @@
+package vault
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type certAuthClient struct {
+}
+
+// NewCertAuthClient returns a new instance of an auth client
+func NewCertAuthClient() AuthClient {
+	return &certAuthClient{}
+}
+
+func (a *certAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/cert/GetVaultToken", vc.config.tracePrefix))()
+
+	vaultResp, err := a.login(vc)
+	if err != nil {
+		return "", err
+	}
+
+	return vaultResp.Auth.ClientToken, nil
+}
+
+func (a *certAuthClient) login(vc *vaultClient) (*api.Secret, error) {
+	vaultResp, err := a.certVaultAuth(vc)
+	if err != nil {
+		return nil, &AuthError{Method: "cert", Err: err}
+	}
+
+	return vaultResp, nil
+}
+
+// certVaultAuth sends a login request to vault, which authenticates the
+// client certificate presented on the TLS connection. Without a role name,
+// vault tries every role of the auth method.
+func (a *certAuthClient) certVaultAuth(vc *vaultClient) (*api.Secret, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/cert/vaultLogin", vc.config.tracePrefix))()
+
+	data := map[string]interface{}{}
+	if vc.config.certRole != "" {
+		data["name"] = vc.config.certRole
+	}
+
+	vaultResp, err := vc.client.Logical().WriteWithContext(vc.ctx, "auth/"+vc.config.certAuthPath+"/login", data)
+	if err != nil {
+		return nil, fmt.Errorf("logging into vault with cert:%w", err)
+	}
+
+	if vaultResp == nil || vaultResp.Auth == nil {
+		return nil, fmt.Errorf("logging into vault with cert: no auth info returned")
+	}
+
+	return vaultResp, nil
+}
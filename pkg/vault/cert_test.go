@@ -0,0 +1,186 @@
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestCertAuth(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/cert/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, cluster.CACertPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, certFile, keyFile := clientCertificate(t, dir)
+
+	root := cluster.Cores[0].Client
+	if err := root.Sys().PutPolicy("cert-read", `path "kv/*" { capabilities = ["read"] }`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := root.Sys().EnableAuthWithOptions("cert", &api.EnableAuthOptions{Type: "cert"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := root.Logical().Write("auth/cert/certs/app", map[string]interface{}{
+		"certificate": certPEM,
+		"policies":    "cert-read",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("options", testCertAuthOptions(root.Address(), caFile, certFile, keyFile))
+	t.Run("environment", testCertAuthEnvironment(root.Address(), caFile, certFile, keyFile))
+	t.Run("secret references", testCertAuthSecretRefs(root.Address(), caFile, certFile, keyFile))
+	t.Run("no client certificate", testCertAuthNoClientCert(root.Address(), caFile))
+}
+
+func testCertAuthOptions(address, caFile, certFile, keyFile string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		c, err := NewClient(context.Background(),
+			WithAddress(address),
+			WithCACert(caFile),
+			WithClientCert(certFile, keyFile),
+			WithCertAuth("", "app"),
+		)
+		is.NoErr(err)
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine], map[string]string{secretKey: secretValue})
+	}
+}
+
+func testCertAuthEnvironment(address, caFile, certFile, keyFile string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		t.Setenv("GITHUB_OAUTH_TOKEN", "")
+		t.Setenv("K8S_AUTH_ROLE", "")
+		t.Setenv("APPROLE_ROLE_ID", "")
+		t.Setenv("APPROLE_ROLE_ID_FILE", "")
+		t.Setenv("VAULT_ADDR", address)
+		t.Setenv("VAULT_CACERT", caFile)
+		t.Setenv("VAULT_CLIENT_CERT", certFile)
+		t.Setenv("VAULT_CLIENT_KEY", keyFile)
+		t.Setenv("CERT_AUTH_ROLE", "app")
+
+		c, err := NewClient(context.Background())
+		is.NoErr(err)
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine], map[string]string{secretKey: secretValue})
+	}
+}
+
+func testCertAuthSecretRefs(address, caFile, certFile, keyFile string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		secrets := map[string]string{}
+		for ref, file := range map[string]string{
+			"berglas://bucket/ca":     caFile,
+			"berglas://bucket/cert":   certFile,
+			"sm://project/client-key": keyFile,
+		} {
+			data, err := os.ReadFile(file)
+			is.NoErr(err)
+			secrets[ref] = string(data)
+		}
+		fakeBerglas(t, secrets)
+
+		c, err := NewClient(context.Background(),
+			WithAddress(address),
+			WithCACert("berglas://bucket/ca"),
+			WithClientCert("berglas://bucket/cert", "gcp-secretmanager://project/client-key"),
+			WithCertAuth("", "app"),
+		)
+		is.NoErr(err)
+
+		secretValues := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secretValues, []string{secretEngine}))
+		is.Equal(secretValues[secretEngine], map[string]string{secretKey: secretValue})
+	}
+}
+
+func testCertAuthNoClientCert(address, caFile string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := NewClient(context.Background(),
+			WithAddress(address),
+			WithCACert(caFile),
+			WithCertAuth("", "app"),
+		)
+		is.True(err != nil)
+
+		var authErr *AuthError
+		is.True(errors.As(err, &authErr))
+		is.Equal(authErr.Method, "cert")
+	}
+}
+
+// clientCertificate writes a self-signed client certificate and its key to
+// dir, and returns the certificate PEM and both file names.
+func clientCertificate(t *testing.T, dir string) (string, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return string(certPEM), certFile, keyFile
+}
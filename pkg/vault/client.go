@@ -0,0 +1,393 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Client is a reusable Vault client. It logs in once when it is created and
+// exposes the secret operations of this package as methods.
+type Client struct {
+	vc        *vaultClient
+	renewer   *tokenRenewer
+	closeOnce sync.Once
+}
+
+// NewClient configures a Client with the given options and logs in to Vault.
+// When no option selects an auth method, the configuration is loaded from the
+// environment the same way the package level functions load it, with the
+// options applied on top.
+func NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	return newClient(ctx, true, opts...)
+}
+
+// newClient configures a Client like NewClient. Unless fromEnv is set,
+// nothing is read from the environment: settings the options leave empty keep
+// their defaults.
+func newClient(ctx context.Context, fromEnv bool, opts ...Option) (*Client, error) {
+	c := &config{tracePrefix: defaultTracePrefix, request: defaultRequestConfig(), ignoreEnv: !fromEnv}
+	if fromEnv {
+		var err error
+		if c.request, err = loadRequestEnvironment(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if !c.hasAuth() && fromEnv {
+		env, err := getConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		c.inheritEnvironment(env)
+	}
+
+	vc, err := NewVaultClient(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing vault client: %w", err)
+	}
+
+	client := &Client{vc: vc}
+	if c.tokenRenewal {
+		client.renewer = newTokenRenewer(vc, c.tokenHandlers)
+		client.renewer.start(vc.auth)
+	}
+
+	return client, nil
+}
+
+// Close stops the token renewal watcher, if one is running. Calling Close
+// again does nothing.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		if c.renewer != nil {
+			c.renewer.close()
+		}
+	})
+}
+
+// newEnvironmentClient returns a Client configured from the environment.
+func newEnvironmentClient(ctx context.Context) (*Client, error) {
+	config, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := NewVaultClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing vault client: %w", err)
+	}
+
+	return &Client{vc: vc}, nil
+}
+
+// ListEngines returns the secrets engines found at path. Pass a context from
+// ContextWithNamespace to list path in another namespace.
+func (c *Client) ListEngines(ctx context.Context, path string) ([]string, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/ListEngines", vc.config.tracePrefix))()
+
+	engine, err := vc.enginesFromVault(path)
+	if err != nil {
+		return nil, fmt.Errorf("getting engines: %w", err)
+	}
+
+	return engine, nil
+}
+
+// GetSecrets fills a map with the values of secrets pulled from Vault. The
+// secrets are read concurrently, see WithConcurrency. By default it returns
+// the error of the first secret that can't be read, in the order of
+// secretNames; with WithCollectErrors it fills in every secret it can read
+// and returns the errors of all others joined together. Pass a context from
+// ContextWithNamespace to read the secrets from another namespace.
+func (c *Client) GetSecrets(ctx context.Context, secretValues *map[string]map[string]string, secretNames []string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/GetSecrets", vc.config.tracePrefix))()
+
+	results := vc.fetchSecrets(secretNames, !vc.config.collectErrors)
+
+	var errs []error
+	for i, r := range results {
+		// a skipped secret was not read because another one failed, whose
+		// error is returned instead
+		if r.skipped {
+			continue
+		}
+
+		if r.err != nil {
+			if !vc.config.collectErrors {
+				return fmt.Errorf("getting secret: %w", r.err)
+			}
+
+			errs = append(errs, fmt.Errorf("getting secret: %w", r.err))
+			continue
+		}
+
+		(*secretValues)[secretNames[i]] = r.data
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetSecretResults reads secretNames and optionalNames and reports, for each
+// path, whether it was read, its data, its version and its error. Unlike
+// GetSecrets it never stops at a failing path. The returned error joins the
+// errors of the failed paths in secretNames; paths in optionalNames may fail
+// without failing the call.
+func (c *Client) GetSecretResults(ctx context.Context, secretNames, optionalNames []string) (SecretResults, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/GetSecretResults", vc.config.tracePrefix))()
+
+	results := vc.secretResults(secretNames, optionalNames)
+
+	return results, results.Err()
+}
+
+// GetSecretData returns the data of a secret with its original types: strings,
+// json.Number, booleans, lists and nested objects.
+func (c *Client) GetSecretData(ctx context.Context, secretName string) (map[string]interface{}, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/GetSecretData", vc.config.tracePrefix))()
+
+	data, err := vc.SecretDataFromVault(secretName)
+	if err != nil {
+		return nil, fmt.Errorf("getting secret: %w", err)
+	}
+
+	return data, nil
+}
+
+// DecodeSecret reads a secret and decodes its data into v, which should be a
+// pointer to a struct or map. Fields are matched the same way encoding/json
+// matches them, so struct tags like `json:"db_password"` can be used.
+func (c *Client) DecodeSecret(ctx context.Context, secretName string, v interface{}) error {
+	data, err := c.GetSecretData(ctx, secretName)
+	if err != nil {
+		return err
+	}
+
+	return decodeSecret(data, v)
+}
+
+// CreateSecret takes a given key for an engine, and adds a new key/value pair in vault.
+// On KV v2 it fails with a ConflictError if the secret changes after it is
+// read, unless WithConflictRetry is set and a retry succeeds.
+func (c *Client) CreateSecret(ctx context.Context, engine, key, value string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/CreateSecret", vc.config.tracePrefix))()
+
+	if _, err := vc.create(engine, key, value); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateSecret takes a given key for an engine, and modifies its value in vault.
+// On KV v2 it fails with a ConflictError if the secret changes after it is
+// read, unless WithConflictRetry is set and a retry succeeds.
+func (c *Client) UpdateSecret(ctx context.Context, engine, key, value string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/UpdateSecret", vc.config.tracePrefix))()
+
+	if _, err := vc.update(engine, key, value); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSecret takes a given key for an engine, and removes the key/value pair from vault.
+// On KV v2 it fails with a ConflictError if the secret changes after it is
+// read, unless WithConflictRetry is set and a retry succeeds.
+func (c *Client) DeleteSecret(ctx context.Context, engine, key string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/DeleteSecret", vc.config.tracePrefix))()
+
+	if _, err := vc.delete(engine, key); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreatePath takes a given path, and adds it to an existing KV v2 engine.
+func (c *Client) CreatePath(ctx context.Context, path string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/CreatePath", vc.config.tracePrefix))()
+
+	if err := vc.createPath(path); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSecretVersions fills a map with the versions of secrets pulled from Vault.
+func (c *Client) GetSecretVersions(ctx context.Context, secretVersions *map[string]int64, secretNames []string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/GetSecretVersions", vc.config.tracePrefix))()
+
+	for _, secretName := range secretNames {
+		secretVersion, err := vc.SecretVersionFromVault(secretName)
+		if err != nil {
+			return fmt.Errorf("getting secret version: %w", err)
+		}
+
+		(*secretVersions)[secretName] = secretVersion
+	}
+
+	return nil
+}
+
+// GetSecretVersion returns the data of the given version of a KV v2 secret
+// with its original types. It fails with ErrSecretNotFound if the version has
+// been deleted or destroyed.
+func (c *Client) GetSecretVersion(ctx context.Context, secretName string, version int64) (map[string]interface{}, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/GetSecretVersion", vc.config.tracePrefix))()
+
+	data, _, err := vc.readSecretVersion(secretName, version)
+	if err != nil {
+		return nil, fmt.Errorf("getting secret version: %w", err)
+	}
+
+	return data, nil
+}
+
+// GetSecretMetadata returns the metadata of a KV v2 secret, including the
+// history of its versions.
+func (c *Client) GetSecretMetadata(ctx context.Context, secretName string) (*SecretMetadata, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/GetSecretMetadata", vc.config.tracePrefix))()
+
+	metadata, err := vc.secretMetadata(secretName)
+	if err != nil {
+		return nil, fmt.Errorf("getting secret metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// RollbackSecret writes the data of an earlier version of a KV v2 secret as
+// its new latest version. The versions in between are kept.
+func (c *Client) RollbackSecret(ctx context.Context, secretName string, version int64) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/RollbackSecret", vc.config.tracePrefix))()
+
+	if _, err := vc.rollback(secretName, version); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSecretVersions soft deletes versions of a KV v2 secret, or its latest
+// version when no versions are given. Deleted versions can no longer be read,
+// but can be restored with UndeleteSecretVersions. On KV v1 the secret is
+// deleted for good.
+func (c *Client) DeleteSecretVersions(ctx context.Context, secretName string, versions ...int64) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/DeleteSecretVersions", vc.config.tracePrefix))()
+
+	return vc.softDelete(secretName, versions)
+}
+
+// UndeleteSecretVersions restores soft deleted versions of a KV v2 secret.
+func (c *Client) UndeleteSecretVersions(ctx context.Context, secretName string, versions ...int64) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/UndeleteSecretVersions", vc.config.tracePrefix))()
+
+	return vc.undelete(secretName, versions)
+}
+
+// DestroySecretVersions permanently removes the data of versions of a KV v2
+// secret. Their metadata is kept.
+func (c *Client) DestroySecretVersions(ctx context.Context, secretName string, versions ...int64) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/DestroySecretVersions", vc.config.tracePrefix))()
+
+	return vc.destroy(secretName, versions)
+}
+
+// DeleteSecretMetadata permanently removes a KV v2 secret, with all of its
+// versions and metadata.
+func (c *Client) DeleteSecretMetadata(ctx context.Context, secretName string) error {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/DeleteSecretMetadata", vc.config.tracePrefix))()
+
+	return vc.deleteMetadata(secretName)
+}
+
+// CacheStats returns the hit and miss counts of the cache set up with
+// WithCache. They are all zero when caching is disabled.
+func (c *Client) CacheStats() CacheStats {
+	return c.vc.cache.snapshot()
+}
+
+// InvalidateCache removes secretNames from the cache set up with WithCache,
+// or every cached secret if no names are given.
+func (c *Client) InvalidateCache(secretNames ...string) {
+	if c.vc.cache == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(secretNames))
+	for _, secretName := range secretNames {
+		keys = append(keys, c.vc.cacheKey(secretName))
+	}
+
+	c.vc.cache.invalidate(keys...)
+}
+
+// Watch polls the current version of the KV v2 secrets at paths every
+// interval, and sends a SecretChange on the returned channel whenever one of
+// them changes. Only the version is read on every poll; the data is read when
+// the version moves. The channel is closed once ctx is done.
+func (c *Client) Watch(ctx context.Context, paths []string, interval time.Duration) (<-chan SecretChange, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/Watch", vc.config.tracePrefix))()
+
+	return vc.startWatch(ctx, paths, interval)
+}
+
+// NewDatabaseLease issues credentials for role from the database secrets
+// engine mounted at mount, "database" if empty. The lease of the credentials
+// is renewed in the background, and new credentials are issued when it can no
+// longer be renewed. The handlers are called for every renewal, rotation and
+// failure. Call Close on the lease to stop renewing it and revoke it.
+func (c *Client) NewDatabaseLease(ctx context.Context, mount, role string, handlers ...func(CredentialsEvent)) (*DatabaseLease, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/NewDatabaseLease", vc.config.tracePrefix))()
+
+	return vc.newDatabaseLease(mount, role, handlers)
+}
+
+// IssueCertificate issues a certificate from role on the PKI secrets engine
+// mounted at mount, "pki" if empty.
+func (c *Client) IssueCertificate(ctx context.Context, mount, role string, req CertificateRequest) (*Certificate, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/IssueCertificate", vc.config.tracePrefix))()
+
+	return vc.issueCertificate(mount, role, req)
+}
+
+// NewCertificateRenewer issues a certificate like IssueCertificate, and
+// issues a new one every time fraction of the current certificate's lifetime
+// has passed. A fraction outside (0, 1) defaults to 2/3. The handlers are
+// called for every new certificate and failure, and can write it to disk with
+// WriteFiles. Call Close on the renewer to stop it.
+func (c *Client) NewCertificateRenewer(ctx context.Context, mount, role string, req CertificateRequest, fraction float64, handlers ...func(CertificateEvent)) (*CertificateRenewer, error) {
+	vc := c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/NewCertificateRenewer", vc.config.tracePrefix))()
+
+	return vc.newCertificateRenewer(mount, role, req, fraction, handlers)
+}
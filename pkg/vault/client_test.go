@@ -2,12 +2,21 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/builtin/credential/cert"
+	"github.com/hashicorp/vault/builtin/logical/pki"
+	"github.com/hashicorp/vault/builtin/logical/transit"
 	"github.com/matryer/is"
 
+	kubeauth "github.com/hashicorp/vault-plugin-auth-kubernetes"
 	kv "github.com/hashicorp/vault-plugin-secrets-kv"
 	vaulthttp "github.com/hashicorp/vault/http"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -84,7 +93,15 @@ func createTestVault(t *testing.T) *hashivault.TestCluster {
 
 	coreConfig := &hashivault.CoreConfig{
 		LogicalBackends: map[string]logical.Factory{
-			"kv": kv.Factory,
+			"kv":       kv.Factory,
+			"database": fakeDatabaseFactory,
+			"transit":  transit.Factory,
+			"pki":      pki.Factory,
+		},
+		CredentialBackends: map[string]logical.Factory{
+			"kubernetes": kubeauth.Factory,
+			"approle":    approle.Factory,
+			"cert":       cert.Factory,
 		},
 	}
 
@@ -120,3 +137,214 @@ func createTestVault(t *testing.T) *hashivault.TestCluster {
 
 	return cluster
 }
+
+// newTestClient returns a Client for cluster that logs in with its root token,
+// with opts applied on top.
+func newTestClient(t *testing.T, cluster *hashivault.TestCluster, opts ...Option) *Client {
+	t.Helper()
+
+	c, err := NewClient(context.Background(), append([]Option{
+		WithAddress(cluster.Cores[0].Client.Address()),
+		WithTLSConfig(&api.TLSConfig{CACertBytes: cluster.CACertPEM}),
+		WithAuthClient(&tokenAuthClient{token: cluster.RootToken}),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+type tokenAuthClient struct {
+	token  string
+	logins int
+}
+
+func (a *tokenAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
+	a.logins++
+
+	return a.token, nil
+}
+
+func TestNewClient(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/client/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	auth := &tokenAuthClient{token: cluster.RootToken}
+	c := newTestClient(t, cluster, WithAuthClient(auth))
+
+	t.Run("reuses login", testClientReusesLogin(c, auth))
+}
+
+func testClientReusesLogin(c *Client, auth *tokenAuthClient) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+		ctx := context.Background()
+
+		is.NoErr(c.CreateSecret(ctx, secretEngine, "new-key", "new-value"))
+		is.NoErr(c.UpdateSecret(ctx, secretEngine, "new-key", "newer-value"))
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(ctx, &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine], map[string]string{secretKey: secretValue, "new-key": "newer-value"})
+
+		versions := map[string]int64{}
+		is.NoErr(c.GetSecretVersions(ctx, &versions, []string{"kv/metadata/client/foo"}))
+		is.Equal(versions["kv/metadata/client/foo"], int64(3))
+
+		engines, err := c.ListEngines(ctx, "kv/metadata/client")
+		is.NoErr(err)
+		is.Equal(engines, []string{"foo"})
+
+		is.Equal(auth.logins, 1)
+	}
+}
+
+func TestNewClientEnvironment(t *testing.T) {
+	is := is.New(t)
+
+	clearAuthEnvironment(t)
+	t.Setenv("GITHUB_OAUTH_TOKEN", "token")
+	t.Setenv("TRACE_ENABLED", "true")
+	t.Setenv("TRACE_PREFIX", "env")
+
+	// the options are applied once, on top of the environment
+	var applied []*config
+	record := func(c *config) { applied = append(applied, c) }
+
+	_, err := NewClient(context.Background(), record, WithTimeout(time.Second))
+	var missing *MissingConfigError
+	is.True(errors.As(err, &missing))
+	is.Equal(missing.Name, "VAULT_ADDR")
+
+	is.Equal(len(applied), 1)
+	c := applied[0]
+	is.Equal(c.githubToken, "token")
+	is.True(c.traceEnabled)
+	is.Equal(c.tracePrefix, "env")
+	is.Equal(c.request.timeout, time.Second)
+
+	applied = nil
+	_, err = NewClient(context.Background(), record, WithTracing("opt"))
+	is.True(err != nil)
+	is.Equal(len(applied), 1)
+	is.Equal(applied[0].tracePrefix, "opt")
+}
+
+func TestClientClose(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/close/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	c := newTestClient(t, cluster,
+		WithAuthClient(&tokenAuthClient{token: cluster.RootToken}),
+		WithTokenRenewal(),
+	)
+
+	// concurrent and repeated calls are safe
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+	}
+	wg.Wait()
+	c.Close()
+}
+
+func TestTypedSecrets(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/typed/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	_, err := cluster.Cores[0].Client.Logical().Write(secretEngine, map[string]interface{}{
+		"data": map[string]interface{}{
+			secretKey: secretValue,
+			"port":    5432,
+			"enabled": true,
+			"hosts":   []string{"a", "b"},
+			"db":      map[string]interface{}{"user": "admin"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, cluster)
+
+	t.Run("stringified secrets", testStringifiedSecrets(c))
+	t.Run("secret data", testSecretData(c))
+	t.Run("decode secret", testDecodeSecret(c))
+	t.Run("update keeps types", testUpdateKeepsTypes(c))
+}
+
+func testUpdateKeepsTypes(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(c.UpdateSecret(context.Background(), secretEngine, secretKey, "newValue"))
+
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data[secretKey], "newValue")
+		is.Equal(data["port"], json.Number("5432"))
+		is.Equal(data["enabled"], true)
+	}
+}
+
+func testStringifiedSecrets(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine], map[string]string{
+			secretKey: secretValue,
+			"port":    "5432",
+			"enabled": "true",
+			"hosts":   `["a","b"]`,
+			"db":      `{"user":"admin"}`,
+		})
+	}
+}
+
+func testSecretData(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data["port"], json.Number("5432"))
+		is.Equal(data["enabled"], true)
+		is.Equal(data["hosts"], []interface{}{"a", "b"})
+		is.Equal(data["db"], map[string]interface{}{"user": "admin"})
+	}
+}
+
+func testDecodeSecret(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		var secret struct {
+			MyKey   string   `json:"myKey"`
+			Port    int      `json:"port"`
+			Enabled bool     `json:"enabled"`
+			Hosts   []string `json:"hosts"`
+			DB      struct {
+				User string `json:"user"`
+			} `json:"db"`
+		}
+		is.NoErr(c.DecodeSecret(context.Background(), secretEngine, &secret))
+		is.Equal(secret.MyKey, secretValue)
+		is.Equal(secret.Port, 5432)
+		is.True(secret.Enabled)
+		is.Equal(secret.Hosts, []string{"a", "b"})
+		is.Equal(secret.DB.User, "admin")
+	}
+}
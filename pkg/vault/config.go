@@ -1,9 +1,15 @@
 package vault
 
 import (
-	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type config struct {
@@ -14,6 +20,115 @@ type config struct {
 	tracePrefix    string
 	vaultRole      string
 	gcpAuthPath    string
+	address        string
+	namespace      string
+	tlsConfig      *api.TLSConfig
+	authClient     AuthClient
+	logger         *slog.Logger
+	tokenRenewal   bool
+	tokenHandlers  []func(TokenEvent)
+	k8sRole        string
+	k8sAuthPath    string
+	k8sTokenPath   string
+	k8sToken       string
+	approle        appRoleConfig
+	certAuthPath   string
+	certRole       string
+	request        requestConfig
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	keepEnv        bool
+	// ignoreEnv keeps the VAULT_* variables from filling in the settings
+	// left empty, for clients built from a Config
+	ignoreEnv bool
+
+	conflictRetries int
+	conflictBackoff time.Duration
+	concurrency     int
+	collectErrors   bool
+	cacheTTL        time.Duration
+	cacheRevalidate bool
+}
+
+// appRoleConfig holds the AppRole credentials. The role and secret IDs are
+// read from their files at login when not set directly.
+type appRoleConfig struct {
+	authPath     string
+	roleID       string
+	roleIDFile   string
+	secretID     string
+	secretIDFile string
+	wrapped      bool
+}
+
+func (a appRoleConfig) enabled() bool {
+	return len(a.roleID) > 0 || len(a.roleIDFile) > 0
+}
+
+// defaultTracePrefix is the prefix of the span names.
+const defaultTracePrefix = "vault"
+
+// defaultK8sTokenPath is where kubernetes mounts the service account token in a pod.
+const defaultK8sTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// hasAuth reports whether an auth method has been configured.
+func (c *config) hasAuth() bool {
+	return c.authClient != nil || len(c.githubToken) > 0 || len(c.k8sRole) > 0 || c.approle.enabled() || len(c.certAuthPath) > 0 || len(c.project) > 0
+}
+
+// authMethod names the configured auth method the way NewAuthClient picks it.
+func (c *config) authMethod() string {
+	switch {
+	case c.authClient != nil:
+		return "custom"
+	case len(c.githubToken) > 0:
+		return "github"
+	case len(c.k8sRole) > 0:
+		return "kubernetes"
+	case c.approle.enabled():
+		return "approle"
+	case len(c.certAuthPath) > 0:
+		return "cert"
+	case len(c.project) > 0:
+		return "gcp"
+	default:
+		return ""
+	}
+}
+
+// inheritEnvironment takes the auth method and tracing settings of env, the
+// configuration loaded from the environment, for a config whose options select
+// no auth method. Settings the options changed are kept, so the options apply
+// on top of the environment.
+func (c *config) inheritEnvironment(env *config) {
+	c.githubToken = env.githubToken
+	c.k8sRole = env.k8sRole
+	c.k8sAuthPath = env.k8sAuthPath
+	c.k8sTokenPath = env.k8sTokenPath
+	c.certAuthPath = env.certAuthPath
+	c.certRole = env.certRole
+	c.project = env.project
+	c.serviceAccount = env.serviceAccount
+	c.gcpAuthPath = env.gcpAuthPath
+	c.vaultRole = env.vaultRole
+
+	wrapped := c.approle.wrapped
+	c.approle = env.approle
+	c.approle.wrapped = c.approle.wrapped || wrapped
+
+	c.traceEnabled = c.traceEnabled || env.traceEnabled
+	if c.tracePrefix == defaultTracePrefix {
+		c.tracePrefix = env.tracePrefix
+	}
+}
+
+// tls returns the TLS configuration set by the options, creating it if needed.
+func (c *config) tls() *api.TLSConfig {
+	if c.tlsConfig == nil {
+		c.tlsConfig = &api.TLSConfig{}
+	}
+
+	return c.tlsConfig
 }
 
 func loadVaultEnvironment() (*config, error) {
@@ -22,9 +137,15 @@ func loadVaultEnvironment() (*config, error) {
 	traceEnabledString := getEnv("TRACE_ENABLED", "false")
 	c.traceEnabled, _ = strconv.ParseBool(traceEnabledString)
 
-	c.tracePrefix = getEnv("TRACE_PREFIX", "vault")
+	c.tracePrefix = getEnv("TRACE_PREFIX", defaultTracePrefix)
 	if c.tracePrefix == "" {
-		return nil, errors.New("set the TRACE_PREFIX variable from environment")
+		return nil, &MissingConfigError{Name: "TRACE_PREFIX"}
+	}
+
+	var err error
+	c.request, err = loadRequestEnvironment()
+	if err != nil {
+		return nil, err
 	}
 
 	// Prefer github oauth token if available
@@ -34,21 +155,51 @@ func loadVaultEnvironment() (*config, error) {
 		return c, nil
 	}
 
+	// Prefer kubernetes service account auth over GCP when a role is set
+	if role := getEnv("K8S_AUTH_ROLE", ""); len(role) > 0 {
+		c.k8sRole = role
+		c.k8sAuthPath = getEnv("K8S_AUTH_PATH", "kubernetes")
+		c.k8sTokenPath = getEnv("K8S_TOKEN_PATH", defaultK8sTokenPath)
+
+		return c, nil
+	}
+
+	// AppRole is used when a role id is set directly or through a file
+	c.approle = appRoleConfig{
+		authPath:     getEnv("APPROLE_AUTH_PATH", "approle"),
+		roleID:       getEnv("APPROLE_ROLE_ID", ""),
+		roleIDFile:   getEnv("APPROLE_ROLE_ID_FILE", ""),
+		secretID:     getEnv("APPROLE_SECRET_ID", ""),
+		secretIDFile: getEnv("APPROLE_SECRET_ID_FILE", ""),
+	}
+	c.approle.wrapped, _ = strconv.ParseBool(getEnv("APPROLE_SECRET_ID_WRAPPED", "false"))
+	if c.approle.enabled() {
+		return c, nil
+	}
+
+	// cert auth uses the client certificate from VAULT_CLIENT_CERT
+	if role := getEnv("CERT_AUTH_ROLE", ""); len(role) > 0 {
+		c.certAuthPath = getEnv("CERT_AUTH_PATH", "cert")
+		c.certRole = role
+
+		return c, nil
+	}
+
 	c.project = getEnv("GCLOUD_PROJECT", "")
 	if c.project == "" {
-		return nil, errors.New("set the GCLOUD_PROJECT environment variable")
+		return nil, fmt.Errorf("%w: %w", ErrNoAuthMethod, &MissingConfigError{Name: "GCLOUD_PROJECT"})
 	}
 
 	// google injects this env var automatically in gcp environments
 	c.serviceAccount = getEnv("FUNCTION_IDENTITY", "")
 	if c.serviceAccount == "" {
-		return nil, errors.New("set the FUNCTION_IDENTITY environment variable")
+		return nil, &MissingConfigError{Name: "FUNCTION_IDENTITY"}
 	}
 
 	c.gcpAuthPath = getEnv("GCP_AUTH_PATH", "gcp")
 	c.vaultRole = getEnv("VAULT_ROLE", "")
 	if c.vaultRole == "" {
-		return nil, errors.New("set the VAULT_ROLE environment variable")
+		return nil, &MissingConfigError{Name: "VAULT_ROLE"}
 	}
 
 	return c, nil
@@ -0,0 +1,454 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"gopkg.in/yaml.v3"
+)
+
+// Config configures a Client. It can be built in code, or loaded from a YAML,
+// JSON or HCL file with LoadConfig, and is used with NewClientFromConfig.
+// Unlike the package level functions, it lets one process talk to several
+// Vault clusters.
+type Config struct {
+	Address   string `json:"address" yaml:"address" hcl:"address"`
+	Namespace string `json:"namespace" yaml:"namespace" hcl:"namespace"`
+
+	TLS   TLSConfig   `json:"tls" yaml:"tls" hcl:"tls"`
+	Auth  AuthConfig  `json:"auth" yaml:"auth" hcl:"auth"`
+	Trace TraceConfig `json:"trace" yaml:"trace" hcl:"trace"`
+
+	// Timeout bounds every request, like "30s". The default is 60 seconds.
+	Timeout string `json:"timeout" yaml:"timeout" hcl:"timeout"`
+	// MaxRetries is how many times requests failing with a 5xx or 429 status
+	// are retried. The default is 2.
+	MaxRetries *int `json:"max_retries" yaml:"max_retries" hcl:"max_retries"`
+	// RateLimit limits the requests per second, allowing bursts of up to
+	// RateBurst requests. Zero disables the limit.
+	RateLimit float64 `json:"rate_limit" yaml:"rate_limit" hcl:"rate_limit"`
+	RateBurst int     `json:"rate_burst" yaml:"rate_burst" hcl:"rate_burst"`
+}
+
+// TLSConfig configures the TLS connection to Vault.
+type TLSConfig struct {
+	CACert     string `json:"ca_cert" yaml:"ca_cert" hcl:"ca_cert"`
+	CAPath     string `json:"ca_path" yaml:"ca_path" hcl:"ca_path"`
+	ClientCert string `json:"client_cert" yaml:"client_cert" hcl:"client_cert"`
+	ClientKey  string `json:"client_key" yaml:"client_key" hcl:"client_key"`
+	ServerName string `json:"server_name" yaml:"server_name" hcl:"server_name"`
+	SkipVerify bool   `json:"skip_verify" yaml:"skip_verify" hcl:"skip_verify"`
+}
+
+// AuthConfig selects the auth method to log in with. Exactly one of its
+// fields must be set.
+type AuthConfig struct {
+	GitHub     *GitHubAuthConfig     `json:"github" yaml:"github" hcl:"github"`
+	Kubernetes *KubernetesAuthConfig `json:"kubernetes" yaml:"kubernetes" hcl:"kubernetes"`
+	AppRole    *AppRoleAuthConfig    `json:"approle" yaml:"approle" hcl:"approle"`
+	Cert       *CertAuthConfig       `json:"cert" yaml:"cert" hcl:"cert"`
+	GCP        *GCPAuthConfig        `json:"gcp" yaml:"gcp" hcl:"gcp"`
+}
+
+// GitHubAuthConfig logs in with a GitHub personal access token.
+type GitHubAuthConfig struct {
+	Token string `json:"token" yaml:"token" hcl:"token"`
+}
+
+// KubernetesAuthConfig logs in with a kubernetes service account token. Path
+// and TokenPath default to "kubernetes" and the token mounted into every pod.
+type KubernetesAuthConfig struct {
+	Path      string `json:"path" yaml:"path" hcl:"path"`
+	Role      string `json:"role" yaml:"role" hcl:"role"`
+	TokenPath string `json:"token_path" yaml:"token_path" hcl:"token_path"`
+}
+
+// AppRoleAuthConfig logs in with AppRole credentials, set directly or read
+// from files at every login. Path defaults to "approle".
+type AppRoleAuthConfig struct {
+	Path         string `json:"path" yaml:"path" hcl:"path"`
+	RoleID       string `json:"role_id" yaml:"role_id" hcl:"role_id"`
+	RoleIDFile   string `json:"role_id_file" yaml:"role_id_file" hcl:"role_id_file"`
+	SecretID     string `json:"secret_id" yaml:"secret_id" hcl:"secret_id"`
+	SecretIDFile string `json:"secret_id_file" yaml:"secret_id_file" hcl:"secret_id_file"`
+	// Wrapped unwraps the secret ID before logging in.
+	Wrapped bool `json:"wrapped" yaml:"wrapped" hcl:"wrapped"`
+}
+
+// CertAuthConfig logs in with the client certificate of TLSConfig. Path
+// defaults to "cert"; without a role, Vault picks the role that matches the
+// certificate.
+type CertAuthConfig struct {
+	Path string `json:"path" yaml:"path" hcl:"path"`
+	Role string `json:"role" yaml:"role" hcl:"role"`
+}
+
+// GCPAuthConfig logs in with a JWT signed for a GCP service account. Path
+// defaults to "gcp".
+type GCPAuthConfig struct {
+	Path           string `json:"path" yaml:"path" hcl:"path"`
+	Project        string `json:"project" yaml:"project" hcl:"project"`
+	ServiceAccount string `json:"service_account" yaml:"service_account" hcl:"service_account"`
+	Role           string `json:"role" yaml:"role" hcl:"role"`
+}
+
+// TraceConfig enables tracing. Prefix defaults to "vault".
+type TraceConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled" hcl:"enabled"`
+	Prefix  string `json:"prefix" yaml:"prefix" hcl:"prefix"`
+}
+
+// LoadConfig loads a Config from the file at path, decoded as YAML, JSON or
+// HCL depending on its extension, then applies the environment overrides of
+// ApplyEnvironment and validates the result. An empty path loads the Config
+// from the environment only.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	if path != "" {
+		if err := cfg.decodeFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.ApplyEnvironment(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) decodeFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".json":
+		err = json.Unmarshal(data, c)
+	case ".hcl":
+		err = hcl.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("reading config %s: unsupported file extension %q, use .yaml, .yml, .json or .hcl", path, ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("decoding config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ApplyEnvironment overrides the settings of c with the environment variables
+// the package level functions read, for those that are set. An auth method is
+// selected when its role, token or role ID variable is set, replacing the
+// auth method of c if it is another one; the other variables of an auth
+// method only override a method that is already configured.
+func (c *Config) ApplyEnvironment() error {
+	override(&c.Address, "VAULT_ADDR")
+	override(&c.Namespace, "VAULT_NAMESPACE")
+
+	override(&c.TLS.CACert, "VAULT_CACERT")
+	override(&c.TLS.CAPath, "VAULT_CAPATH")
+	override(&c.TLS.ClientCert, "VAULT_CLIENT_CERT")
+	override(&c.TLS.ClientKey, "VAULT_CLIENT_KEY")
+	override(&c.TLS.ServerName, "VAULT_TLS_SERVER_NAME")
+
+	var errs []error
+	errs = append(errs, overrideBool(&c.TLS.SkipVerify, "VAULT_SKIP_VERIFY"))
+	errs = append(errs, overrideBool(&c.Trace.Enabled, "TRACE_ENABLED"))
+	override(&c.Trace.Prefix, "TRACE_PREFIX")
+
+	override(&c.Timeout, "VAULT_CLIENT_TIMEOUT")
+	if v := getEnv("VAULT_MAX_RETRIES", ""); v != "" {
+		retries, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing VAULT_MAX_RETRIES: %w", err))
+		} else {
+			c.MaxRetries = &retries
+		}
+	}
+	if v := getEnv("VAULT_RATE_LIMIT", ""); v != "" {
+		limit, burst, _ := strings.Cut(v, ":")
+
+		rateLimit, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing VAULT_RATE_LIMIT: %w", err))
+		}
+
+		rateBurst := 0
+		if burst != "" {
+			if rateBurst, err = strconv.Atoi(burst); err != nil {
+				errs = append(errs, fmt.Errorf("parsing VAULT_RATE_LIMIT: %w", err))
+			}
+		}
+
+		c.RateLimit, c.RateBurst = rateLimit, rateBurst
+	}
+
+	// an auth method selected in the environment replaces the one of the
+	// file, instead of being reported as a second method
+	a := &c.Auth
+	if (getEnv("GITHUB_OAUTH_TOKEN", "") != "" && a.GitHub == nil) ||
+		(getEnv("K8S_AUTH_ROLE", "") != "" && a.Kubernetes == nil) ||
+		((getEnv("APPROLE_ROLE_ID", "") != "" || getEnv("APPROLE_ROLE_ID_FILE", "") != "") && a.AppRole == nil) ||
+		(getEnv("CERT_AUTH_ROLE", "") != "" && a.Cert == nil) ||
+		(getEnv("VAULT_ROLE", "") != "" && a.GCP == nil) {
+		*a = AuthConfig{}
+	}
+
+	if getEnv("GITHUB_OAUTH_TOKEN", "") != "" && a.GitHub == nil {
+		a.GitHub = &GitHubAuthConfig{}
+	}
+	if a.GitHub != nil {
+		override(&a.GitHub.Token, "GITHUB_OAUTH_TOKEN")
+	}
+
+	if getEnv("K8S_AUTH_ROLE", "") != "" && a.Kubernetes == nil {
+		a.Kubernetes = &KubernetesAuthConfig{}
+	}
+	if a.Kubernetes != nil {
+		override(&a.Kubernetes.Role, "K8S_AUTH_ROLE")
+		override(&a.Kubernetes.Path, "K8S_AUTH_PATH")
+		override(&a.Kubernetes.TokenPath, "K8S_TOKEN_PATH")
+	}
+
+	if (getEnv("APPROLE_ROLE_ID", "") != "" || getEnv("APPROLE_ROLE_ID_FILE", "") != "") && a.AppRole == nil {
+		a.AppRole = &AppRoleAuthConfig{}
+	}
+	if a.AppRole != nil {
+		override(&a.AppRole.Path, "APPROLE_AUTH_PATH")
+		override(&a.AppRole.RoleID, "APPROLE_ROLE_ID")
+		override(&a.AppRole.RoleIDFile, "APPROLE_ROLE_ID_FILE")
+		override(&a.AppRole.SecretID, "APPROLE_SECRET_ID")
+		override(&a.AppRole.SecretIDFile, "APPROLE_SECRET_ID_FILE")
+		errs = append(errs, overrideBool(&a.AppRole.Wrapped, "APPROLE_SECRET_ID_WRAPPED"))
+	}
+
+	if getEnv("CERT_AUTH_ROLE", "") != "" && a.Cert == nil {
+		a.Cert = &CertAuthConfig{}
+	}
+	if a.Cert != nil {
+		override(&a.Cert.Role, "CERT_AUTH_ROLE")
+		override(&a.Cert.Path, "CERT_AUTH_PATH")
+	}
+
+	if getEnv("VAULT_ROLE", "") != "" && a.GCP == nil {
+		a.GCP = &GCPAuthConfig{}
+	}
+	if a.GCP != nil {
+		override(&a.GCP.Role, "VAULT_ROLE")
+		override(&a.GCP.Project, "GCLOUD_PROJECT")
+		override(&a.GCP.ServiceAccount, "FUNCTION_IDENTITY")
+		override(&a.GCP.Path, "GCP_AUTH_PATH")
+	}
+
+	return errors.Join(errs...)
+}
+
+// override sets *field to the value of the environment variable name, if it
+// is set and not empty.
+func override(field *string, name string) {
+	if v := getEnv(name, ""); v != "" {
+		*field = v
+	}
+}
+
+func overrideBool(field *bool, name string) error {
+	v := getEnv(name, "")
+	if v == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+
+	*field = b
+
+	return nil
+}
+
+// Validate checks c and reports every problem it finds at once, joined
+// together. Missing settings are reported as MissingConfigError, named by
+// their key in a config file.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Address == "" {
+		errs = append(errs, &MissingConfigError{Name: "address"})
+	}
+
+	if (c.TLS.ClientCert == "") != (c.TLS.ClientKey == "") {
+		errs = append(errs, errors.New("tls.client_cert and tls.client_key must be set together"))
+	}
+
+	a := c.Auth
+	var methods []string
+	if a.GitHub != nil {
+		methods = append(methods, "github")
+		if a.GitHub.Token == "" {
+			errs = append(errs, &MissingConfigError{Name: "auth.github.token"})
+		}
+	}
+
+	if a.Kubernetes != nil {
+		methods = append(methods, "kubernetes")
+		if a.Kubernetes.Role == "" {
+			errs = append(errs, &MissingConfigError{Name: "auth.kubernetes.role"})
+		}
+	}
+
+	if a.AppRole != nil {
+		methods = append(methods, "approle")
+		// the secret id is optional, for roles with bind_secret_id=false
+		if a.AppRole.RoleID == "" && a.AppRole.RoleIDFile == "" {
+			errs = append(errs, &MissingConfigError{Name: "auth.approle.role_id"})
+		}
+	}
+
+	if a.Cert != nil {
+		methods = append(methods, "cert")
+		if c.TLS.ClientCert == "" {
+			errs = append(errs, &MissingConfigError{Name: "tls.client_cert"})
+		}
+	}
+
+	if a.GCP != nil {
+		methods = append(methods, "gcp")
+		if a.GCP.Project == "" {
+			errs = append(errs, &MissingConfigError{Name: "auth.gcp.project"})
+		}
+		if a.GCP.ServiceAccount == "" {
+			errs = append(errs, &MissingConfigError{Name: "auth.gcp.service_account"})
+		}
+		if a.GCP.Role == "" {
+			errs = append(errs, &MissingConfigError{Name: "auth.gcp.role"})
+		}
+	}
+
+	switch {
+	case len(methods) == 0:
+		errs = append(errs, fmt.Errorf("%w: one of auth.github, auth.kubernetes, auth.approle, auth.cert or auth.gcp must be set", ErrNoAuthMethod))
+	case len(methods) > 1:
+		errs = append(errs, fmt.Errorf("only one auth method can be set, got %s", strings.Join(methods, ", ")))
+	}
+
+	if c.Timeout != "" {
+		if timeout, err := parseSeconds(c.Timeout); err != nil || timeout < 0 {
+			errs = append(errs, fmt.Errorf("timeout %q is not a valid duration", c.Timeout))
+		}
+	}
+
+	if c.MaxRetries != nil && *c.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("max_retries must not be negative, got %d", *c.MaxRetries))
+	}
+
+	if c.RateLimit < 0 || c.RateBurst < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit and rate_burst must not be negative, got %g and %d", c.RateLimit, c.RateBurst))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Options validates c and returns the options that configure a Client like
+// it.
+func (c *Config) Options() ([]Option, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	opts := []Option{WithAddress(c.Address)}
+
+	if c.Namespace != "" {
+		opts = append(opts, WithNamespace(c.Namespace))
+	}
+
+	if c.TLS.CACert != "" {
+		opts = append(opts, WithCACert(c.TLS.CACert))
+	}
+	if c.TLS.CAPath != "" {
+		opts = append(opts, WithCAPath(c.TLS.CAPath))
+	}
+	if c.TLS.ClientCert != "" {
+		opts = append(opts, WithClientCert(c.TLS.ClientCert, c.TLS.ClientKey))
+	}
+	if c.TLS.ServerName != "" {
+		opts = append(opts, WithTLSServerName(c.TLS.ServerName))
+	}
+	if c.TLS.SkipVerify {
+		opts = append(opts, WithTLSSkipVerify())
+	}
+
+	a := c.Auth
+	switch {
+	case a.GitHub != nil:
+		opts = append(opts, WithGithubAuth(a.GitHub.Token))
+	case a.Kubernetes != nil:
+		opts = append(opts, WithKubernetesAuth(a.Kubernetes.Path, a.Kubernetes.Role, a.Kubernetes.TokenPath))
+	case a.AppRole != nil:
+		opts = append(opts,
+			WithAppRoleAuth(a.AppRole.Path, a.AppRole.RoleID, a.AppRole.SecretID),
+			WithAppRoleAuthFiles(a.AppRole.Path, a.AppRole.RoleIDFile, a.AppRole.SecretIDFile),
+		)
+		if a.AppRole.Wrapped {
+			opts = append(opts, WithWrappedSecretID())
+		}
+	case a.Cert != nil:
+		opts = append(opts, WithCertAuth(a.Cert.Path, a.Cert.Role))
+	case a.GCP != nil:
+		path := a.GCP.Path
+		opts = append(opts, func(c *config) { c.gcpAuthPath = path }, WithGCPAuth(a.GCP.Project, a.GCP.ServiceAccount, a.GCP.Role))
+	}
+
+	if c.Trace.Enabled {
+		prefix := c.Trace.Prefix
+		if prefix == "" {
+			prefix = defaultTracePrefix
+		}
+
+		opts = append(opts, WithTracing(prefix))
+	}
+
+	if c.Timeout != "" {
+		timeout, _ := parseSeconds(c.Timeout)
+		opts = append(opts, WithTimeout(timeout))
+	}
+
+	if c.MaxRetries != nil {
+		opts = append(opts, WithRetry(*c.MaxRetries, defaultMinRetryWait, defaultMaxRetryWait))
+	}
+
+	if c.RateLimit > 0 {
+		opts = append(opts, WithRateLimit(c.RateLimit, c.RateBurst))
+	}
+
+	return opts, nil
+}
+
+// NewClientFromConfig validates cfg and returns a Client configured by it,
+// with opts applied on top. Nothing is read from the environment, so clients
+// for different Vault clusters can live in one process; call ApplyEnvironment
+// on cfg, as LoadConfig does, to take the VAULT_* variables into account.
+func NewClientFromConfig(ctx context.Context, cfg Config, opts ...Option) (*Client, error) {
+	cfgOpts, err := cfg.Options()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return newClient(ctx, false, append(cfgOpts, opts...)...)
+}
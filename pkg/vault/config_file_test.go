@@ -0,0 +1,295 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+const yamlConfig = `
+address: https://vault.example.com:8200
+namespace: team
+tls:
+  ca_cert: /etc/vault/ca.pem
+  server_name: vault.example.com
+auth:
+  approle:
+    path: workers
+    role_id: role
+    secret_id_file: /var/run/secret-id
+    wrapped: true
+trace:
+  enabled: true
+  prefix: worker
+timeout: 30s
+max_retries: 5
+rate_limit: 10
+rate_burst: 20
+`
+
+const jsonConfig = `{
+  "address": "https://vault.example.com:8200",
+  "namespace": "team",
+  "tls": {"ca_cert": "/etc/vault/ca.pem", "server_name": "vault.example.com"},
+  "auth": {
+    "approle": {"path": "workers", "role_id": "role", "secret_id_file": "/var/run/secret-id", "wrapped": true}
+  },
+  "trace": {"enabled": true, "prefix": "worker"},
+  "timeout": "30s",
+  "max_retries": 5,
+  "rate_limit": 10,
+  "rate_burst": 20
+}`
+
+const hclConfig = `
+address   = "https://vault.example.com:8200"
+namespace = "team"
+
+tls {
+  ca_cert     = "/etc/vault/ca.pem"
+  server_name = "vault.example.com"
+}
+
+auth {
+  approle {
+    path           = "workers"
+    role_id        = "role"
+    secret_id_file = "/var/run/secret-id"
+    wrapped        = true
+  }
+}
+
+trace {
+  enabled = true
+  prefix  = "worker"
+}
+
+timeout     = "30s"
+max_retries = 5
+rate_limit  = 10
+rate_burst  = 20
+`
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// clearAuthEnvironment unsets the auth method variables other tests leave
+// behind.
+func clearAuthEnvironment(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{"GITHUB_OAUTH_TOKEN", "K8S_AUTH_ROLE", "APPROLE_ROLE_ID", "APPROLE_ROLE_ID_FILE", "CERT_AUTH_ROLE", "VAULT_ROLE", "VAULT_ADDR"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	clearAuthEnvironment(t)
+
+	retries := 5
+	want := Config{
+		Address:   "https://vault.example.com:8200",
+		Namespace: "team",
+		TLS:       TLSConfig{CACert: "/etc/vault/ca.pem", ServerName: "vault.example.com"},
+		Auth: AuthConfig{AppRole: &AppRoleAuthConfig{
+			Path:         "workers",
+			RoleID:       "role",
+			SecretIDFile: "/var/run/secret-id",
+			Wrapped:      true,
+		}},
+		Trace:      TraceConfig{Enabled: true, Prefix: "worker"},
+		Timeout:    "30s",
+		MaxRetries: &retries,
+		RateLimit:  10,
+		RateBurst:  20,
+	}
+
+	for name, contents := range map[string]string{
+		"vault.yaml": yamlConfig,
+		"vault.yml":  yamlConfig,
+		"vault.json": jsonConfig,
+		"vault.hcl":  hclConfig,
+	} {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			cfg, err := LoadConfig(writeConfigFile(t, name, contents))
+			is.NoErr(err)
+			is.Equal(*cfg.Auth.AppRole, *want.Auth.AppRole)
+			is.Equal(*cfg.MaxRetries, *want.MaxRetries)
+
+			cfg.Auth.AppRole, cfg.MaxRetries = want.Auth.AppRole, want.MaxRetries
+			is.Equal(*cfg, want)
+		})
+	}
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := LoadConfig(writeConfigFile(t, "vault.toml", ""))
+		is.True(err != nil)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := LoadConfig(writeConfigFile(t, "vault.json", "{"))
+		is.True(err != nil)
+	})
+}
+
+func TestLoadConfigEnvironment(t *testing.T) {
+	is := is.New(t)
+	clearAuthEnvironment(t)
+
+	t.Setenv("VAULT_ADDR", "https://other.example.com:8200")
+	t.Setenv("APPROLE_SECRET_ID", "secret")
+	t.Setenv("VAULT_MAX_RETRIES", "1")
+	t.Setenv("VAULT_RATE_LIMIT", "2:4")
+	t.Setenv("TRACE_ENABLED", "false")
+
+	cfg, err := LoadConfig(writeConfigFile(t, "vault.yaml", yamlConfig))
+	is.NoErr(err)
+
+	is.Equal(cfg.Address, "https://other.example.com:8200")
+	is.Equal(cfg.Namespace, "team")
+	is.Equal(cfg.Auth.AppRole.RoleID, "role")
+	is.Equal(cfg.Auth.AppRole.SecretID, "secret")
+	is.Equal(*cfg.MaxRetries, 1)
+	is.Equal(cfg.RateLimit, 2.0)
+	is.Equal(cfg.RateBurst, 4)
+	is.True(!cfg.Trace.Enabled)
+
+	// variables of an auth method that is not configured do not add it
+	t.Setenv("K8S_AUTH_PATH", "k8s")
+	cfg, err = LoadConfig(writeConfigFile(t, "vault.yaml", yamlConfig))
+	is.NoErr(err)
+	is.True(cfg.Auth.Kubernetes == nil)
+
+	// the key variable of an auth method does
+	t.Setenv("K8S_AUTH_ROLE", "worker")
+	cfg, err = LoadConfig("")
+	is.NoErr(err)
+	is.Equal(*cfg.Auth.Kubernetes, KubernetesAuthConfig{Path: "k8s", Role: "worker"})
+
+	// and replaces the auth method of the file
+	cfg, err = LoadConfig(writeConfigFile(t, "vault.yaml", yamlConfig))
+	is.NoErr(err)
+	is.Equal(cfg.Auth, AuthConfig{Kubernetes: &KubernetesAuthConfig{Path: "k8s", Role: "worker"}})
+
+	t.Setenv("VAULT_SKIP_VERIFY", "maybe")
+	_, err = LoadConfig("")
+	is.True(err != nil)
+}
+
+func TestConfigValidate(t *testing.T) {
+	is := is.New(t)
+
+	retries := -1
+	cfg := Config{
+		TLS:        TLSConfig{ClientKey: "key.pem"},
+		Auth:       AuthConfig{GCP: &GCPAuthConfig{Project: "project"}},
+		Timeout:    "soon",
+		MaxRetries: &retries,
+	}
+
+	err := cfg.Validate()
+	is.True(err != nil)
+
+	var missing []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var m *MissingConfigError
+		if errors.As(err, &m) {
+			missing = append(missing, m.Name)
+		}
+	}
+	is.Equal(missing, []string{"address", "auth.gcp.service_account", "auth.gcp.role"})
+	is.True(!errors.Is(err, ErrNoAuthMethod))
+	// address, client cert pair, two gcp fields, timeout and retries
+	is.Equal(len(err.(interface{ Unwrap() []error }).Unwrap()), 6)
+
+	cfg = Config{
+		Address: "https://vault.example.com:8200",
+	}
+	is.True(errors.Is(cfg.Validate(), ErrNoAuthMethod))
+
+	cfg.Auth = AuthConfig{
+		GitHub: &GitHubAuthConfig{Token: "token"},
+		Cert:   &CertAuthConfig{},
+	}
+	err = cfg.Validate()
+	is.True(err != nil)
+	var m *MissingConfigError
+	is.True(errors.As(err, &m))
+	is.Equal(m.Name, "tls.client_cert")
+
+	cfg.Auth.Cert = nil
+	is.NoErr(cfg.Validate())
+
+	// roles with bind_secret_id=false need no secret id
+	cfg.Auth = AuthConfig{AppRole: &AppRoleAuthConfig{RoleID: "role"}}
+	is.NoErr(cfg.Validate())
+
+	cfg.Auth = AuthConfig{AppRole: &AppRoleAuthConfig{SecretID: "secret"}}
+	is.True(errors.As(cfg.Validate(), &m))
+	is.Equal(m.Name, "auth.approle.role_id")
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	is := is.New(t)
+
+	// none of these are read
+	clearAuthEnvironment(t)
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:1")
+	t.Setenv("VAULT_NAMESPACE", "env")
+
+	// one process talking to two Vault clusters
+	first, second := newNamespaceServer(), newNamespaceServer()
+	defer first.Close()
+	defer second.Close()
+
+	clients := map[*namespaceServer]*Client{}
+	for s, namespace := range map[*namespaceServer]string{first: "first", second: "second"} {
+		c, err := NewClientFromConfig(context.Background(), Config{
+			Address:   s.URL,
+			Namespace: namespace,
+			Auth:      AuthConfig{GitHub: &GitHubAuthConfig{Token: "unused"}},
+		}, WithAuthClient(&tokenAuthClient{token: "token"}))
+		is.NoErr(err)
+
+		clients[s] = c
+	}
+
+	_, err := clients[first].ListEngines(context.Background(), "kv/metadata/cluster")
+	is.NoErr(err)
+	_, err = clients[second].ListEngines(context.Background(), "kv/metadata/cluster")
+	is.NoErr(err)
+
+	is.Equal(first.namespace("kv/metadata/cluster"), "first")
+	is.Equal(second.namespace("kv/metadata/cluster"), "second")
+
+	// a namespace left empty is not taken from VAULT_NAMESPACE
+	c, err := NewClientFromConfig(context.Background(), Config{
+		Address: first.URL,
+		Auth:    AuthConfig{GitHub: &GitHubAuthConfig{Token: "unused"}},
+	}, WithAuthClient(&tokenAuthClient{token: "token"}))
+	is.NoErr(err)
+	_, err = c.ListEngines(context.Background(), "kv/metadata/root")
+	is.NoErr(err)
+	is.Equal(first.namespace("kv/metadata/root"), "")
+
+	_, err = NewClientFromConfig(context.Background(), Config{})
+	is.True(errors.Is(err, ErrNoAuthMethod))
+}
@@ -0,0 +1,268 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// DatabaseCredentials are dynamic credentials issued by the database secrets
+// engine.
+type DatabaseCredentials struct {
+	Username      string
+	Password      string
+	LeaseID       string
+	LeaseDuration time.Duration
+	Renewable     bool
+}
+
+// CredentialsEventType identifies the kind of a CredentialsEvent.
+type CredentialsEventType int
+
+const (
+	// CredentialsRenewed is sent after the lease of the credentials has been
+	// renewed.
+	CredentialsRenewed CredentialsEventType = iota
+	// CredentialsRotated is sent after new credentials have been issued
+	// because the lease of the old ones could not be renewed any further.
+	// Connections should be reopened with the new credentials before the old
+	// ones expire.
+	CredentialsRotated
+	// CredentialsFailed is sent when renewing the lease or issuing new
+	// credentials fails.
+	CredentialsFailed
+)
+
+// CredentialsEvent reports a change in the lifetime of DatabaseLease
+// credentials.
+type CredentialsEvent struct {
+	Type CredentialsEventType
+	// Credentials are the current credentials. They are the zero value for
+	// CredentialsFailed.
+	Credentials DatabaseCredentials
+	// Err is set for CredentialsFailed.
+	Err error
+}
+
+// DatabaseLease holds credentials issued by the database secrets engine and
+// keeps their lease alive until Close is called.
+type DatabaseLease struct {
+	vc       *vaultClient
+	path     string
+	handlers []func(CredentialsEvent)
+	retry    time.Duration
+
+	mu     sync.RWMutex
+	secret *api.Secret
+	creds  DatabaseCredentials
+
+	stop chan struct{}
+	done chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Credentials returns the current credentials.
+func (l *DatabaseLease) Credentials() DatabaseCredentials {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.creds
+}
+
+// Close stops renewing the lease and revokes it, so the database user is
+// dropped. Calling Close again returns the result of the first call.
+func (l *DatabaseLease) Close() error {
+	l.closeOnce.Do(func() {
+		l.closeErr = l.close()
+	})
+
+	return l.closeErr
+}
+
+func (l *DatabaseLease) close() error {
+	close(l.stop)
+	<-l.done
+
+	leaseID := l.Credentials().LeaseID
+	if leaseID == "" {
+		return nil
+	}
+
+	if err := l.vc.client.Sys().RevokeWithContext(l.vc.ctx, leaseID); err != nil {
+		return fmt.Errorf("revoking lease %s: %w", leaseID, err)
+	}
+
+	return nil
+}
+
+// readDatabaseCredentials issues new credentials by reading path, the creds
+// path of a database secrets engine role.
+func (vc *vaultClient) readDatabaseCredentials(path string) (*api.Secret, DatabaseCredentials, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/readDatabaseCredentials", vc.config.tracePrefix))()
+
+	secret, err := vc.client.Logical().ReadWithContext(vc.ctx, path)
+	if err != nil {
+		return nil, DatabaseCredentials{}, fmt.Errorf("reading database credentials from %s: %w", path, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, DatabaseCredentials{}, fmt.Errorf("database credentials returned from Vault are <nil> for %s: %w", path, ErrSecretNotFound)
+	}
+
+	username, _ := secret.Data["username"].(string)
+	password, _ := secret.Data["password"].(string)
+	if username == "" || password == "" {
+		return nil, DatabaseCredentials{}, fmt.Errorf("no username or password returned from %s", path)
+	}
+
+	return secret, DatabaseCredentials{
+		Username:      username,
+		Password:      password,
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: time.Duration(secret.LeaseDuration) * time.Second,
+		Renewable:     secret.Renewable,
+	}, nil
+}
+
+// newDatabaseLease issues credentials and starts renewing their lease.
+func (vc *vaultClient) newDatabaseLease(mount, role string, handlers []func(CredentialsEvent)) (*DatabaseLease, error) {
+	if mount == "" {
+		mount = "database"
+	}
+
+	l := &DatabaseLease{
+		vc:       vc.withContext(context.WithoutCancel(vc.ctx)),
+		path:     fmt.Sprintf("%s/creds/%s", mount, role),
+		handlers: handlers,
+		retry:    defaultReloginInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	secret, creds, err := l.vc.readDatabaseCredentials(l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	l.secret, l.creds = secret, creds
+	go l.run()
+
+	return l, nil
+}
+
+func (l *DatabaseLease) run() {
+	defer close(l.done)
+
+	for {
+		l.mu.RLock()
+		secret, creds := l.secret, l.creds
+		l.mu.RUnlock()
+
+		switch {
+		case creds.Renewable:
+			if !l.watch(secret) {
+				return
+			}
+		case creds.LeaseDuration > 0:
+			// rotate shortly before credentials that can't be renewed expire
+			if !l.wait(creds.LeaseDuration * 9 / 10) {
+				return
+			}
+		default:
+			// the credentials never expire
+			<-l.stop
+			return
+		}
+
+		for err := l.rotate(); err != nil; err = l.rotate() {
+			l.notify(CredentialsEvent{Type: CredentialsFailed, Err: err})
+
+			if !l.wait(l.retry) {
+				return
+			}
+		}
+	}
+}
+
+// watch renews the lease of secret until it can no longer be renewed. It
+// returns false if the lease was closed.
+func (l *DatabaseLease) watch(secret *api.Secret) bool {
+	watcher, err := l.vc.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		l.notify(CredentialsEvent{Type: CredentialsFailed, Err: fmt.Errorf("starting lease watcher: %w", err)})
+		return true
+	}
+
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return false
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				l.notify(CredentialsEvent{Type: CredentialsFailed, Err: fmt.Errorf("renewing lease: %w", err)})
+			}
+
+			return true
+		case renewal := <-watcher.RenewCh():
+			l.mu.Lock()
+			l.creds.LeaseDuration = time.Duration(renewal.Secret.LeaseDuration) * time.Second
+			creds := l.creds
+			l.mu.Unlock()
+
+			l.notify(CredentialsEvent{Type: CredentialsRenewed, Credentials: creds})
+		}
+	}
+}
+
+// rotate issues new credentials to replace the current ones. The old lease
+// is left to expire, so connections using it keep working until then.
+func (l *DatabaseLease) rotate() error {
+	secret, creds, err := l.vc.readDatabaseCredentials(l.path)
+	if err != nil {
+		return fmt.Errorf("rotating credentials: %w", err)
+	}
+
+	l.mu.Lock()
+	l.secret, l.creds = secret, creds
+	l.mu.Unlock()
+
+	l.notify(CredentialsEvent{Type: CredentialsRotated, Credentials: creds})
+
+	return nil
+}
+
+// wait blocks for d, returning false if the lease was closed first.
+func (l *DatabaseLease) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-l.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (l *DatabaseLease) notify(e CredentialsEvent) {
+	switch e.Type {
+	case CredentialsRenewed:
+		l.vc.logger().Debug("renewed database credentials", "path", l.path, "username", e.Credentials.Username, "ttl", e.Credentials.LeaseDuration)
+	case CredentialsRotated:
+		l.vc.logger().Info("rotated database credentials", "path", l.path, "username", e.Credentials.Username, "ttl", e.Credentials.LeaseDuration)
+	case CredentialsFailed:
+		l.vc.logger().Warn("keeping database credentials alive failed", "path", l.path, "error", e.Err)
+	}
+
+	for _, handler := range l.handlers {
+		handler(e)
+	}
+}
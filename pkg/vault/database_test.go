@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/matryer/is"
+)
+
+var (
+	issuedCredentials atomic.Int64
+	// revokedUsers holds the usernames of revoked credentials.
+	revokedUsers sync.Map
+)
+
+// fakeDatabaseFactory creates a backend that issues credentials the way the
+// database secrets engine does, with a 2s TTL and a 4s max TTL.
+func fakeDatabaseFactory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := &framework.Backend{BackendType: logical.TypeLogical}
+
+	b.Secrets = []*framework.Secret{{
+		Type: "creds",
+		Renew: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+			resp := &logical.Response{Secret: req.Secret}
+			resp.Secret.TTL = 2 * time.Second
+			resp.Secret.MaxTTL = 4 * time.Second
+
+			return resp, nil
+		},
+		Revoke: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+			revokedUsers.Store(req.Secret.InternalData["username"], true)
+
+			return nil, nil
+		},
+	}}
+
+	b.Paths = []*framework.Path{{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {Type: framework.TypeString},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+					n := issuedCredentials.Add(1)
+					username := fmt.Sprintf("%s-%d", d.Get("name"), n)
+					resp := b.Secret("creds").Response(map[string]interface{}{
+						"username": username,
+						"password": fmt.Sprintf("password-%d", n),
+					}, map[string]interface{}{
+						"username": username,
+					})
+					resp.Secret.TTL = 2 * time.Second
+					resp.Secret.MaxTTL = 4 * time.Second
+
+					return resp, nil
+				},
+			},
+		},
+	}}
+
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func TestDatabaseLease(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/database/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	if err := rootVaultClient.Sys().Mount("database", &api.MountInput{Type: "database"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, cluster)
+
+	t.Run("renews, rotates and revokes", testDatabaseLease(c))
+	t.Run("missing role", testDatabaseLeaseMissingMount(c))
+}
+
+func testDatabaseLease(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		events := make(chan CredentialsEvent, 100)
+		lease, err := c.NewDatabaseLease(context.Background(), "", "app", func(e CredentialsEvent) { events <- e })
+		is.NoErr(err)
+
+		first := lease.Credentials()
+		is.True(first.Username != "")
+		is.True(first.Password != "")
+		is.True(first.LeaseID != "")
+		is.True(first.Renewable)
+		is.Equal(first.LeaseDuration, 2*time.Second)
+
+		seen := map[CredentialsEventType]bool{}
+		timeout := time.After(20 * time.Second)
+		for !seen[CredentialsRenewed] || !seen[CredentialsRotated] {
+			select {
+			case e := <-events:
+				is.NoErr(e.Err)
+				seen[e.Type] = true
+			case <-timeout:
+				t.Fatalf("events seen before timeout: %v", seen)
+			}
+		}
+
+		rotated := lease.Credentials()
+		is.True(rotated.Username != first.Username)
+		is.True(rotated.LeaseID != first.LeaseID)
+
+		is.NoErr(lease.Close())
+		_, revoked := revokedUsers.Load(rotated.Username)
+		is.True(revoked)
+
+		// closing again does nothing
+		is.NoErr(lease.Close())
+	}
+}
+
+func testDatabaseLeaseMissingMount(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := c.NewDatabaseLease(context.Background(), "missing", "app")
+		is.True(err != nil)
+	}
+}
@@ -2,19 +2,23 @@ package vault
 
 import (
 	"fmt"
+	"time"
 )
 
 // enginesFromVault takes a path and returns a list of engines from vault.
-func (vc *vaultClient) enginesFromVault(path string) ([]string, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/enginesFromVault", vc.config.tracePrefix))
+func (vc *vaultClient) enginesFromVault(path string) (_ []string, err error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/enginesFromVault", vc.config.tracePrefix))()
 
-	engines, err := vc.client.Logical().List(path)
+	mount := vc.kvMount(path)
+	defer vc.observe(opList, time.Now(), &err, pathAttributes(path, mount)...)
+
+	engines, err := vc.client.Logical().ListWithContext(vc.ctx, mount.apiPath(path, "metadata"))
 	if err != nil {
-		return nil, fmt.Errorf("listing engines from Vault for %s", path)
+		return nil, fmt.Errorf("listing engines from Vault for %s: %w", path, err)
 	}
 
 	if engines == nil {
-		return nil, fmt.Errorf("engines returned from Vault are <nil> for %s", path)
+		return nil, fmt.Errorf("engines returned from Vault are <nil> for %s: %w", path, ErrSecretNotFound)
 	}
 
 	engineData, _ := extractListData(engines)
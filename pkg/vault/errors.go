@@ -0,0 +1,81 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoAuthMethod is returned when none of the supported auth methods is configured.
+	ErrNoAuthMethod = errors.New("no vault auth method configured")
+
+	// ErrMissingConfig is matched by every MissingConfigError.
+	ErrMissingConfig = errors.New("missing configuration")
+
+	// ErrAuthFailed is matched by every AuthError.
+	ErrAuthFailed = errors.New("vault authentication failed")
+
+	// ErrSecretNotFound is returned when Vault has no secret at the requested path.
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrConflict is matched by every ConflictError.
+	ErrConflict = errors.New("secret was modified concurrently")
+)
+
+// MissingConfigError reports a required configuration value that is not set.
+type MissingConfigError struct {
+	// Name is the environment variable the value is read from.
+	Name string
+}
+
+func (e *MissingConfigError) Error() string {
+	return fmt.Sprintf("set the %s environment variable", e.Name)
+}
+
+// Is makes a MissingConfigError match ErrMissingConfig.
+func (e *MissingConfigError) Is(target error) bool {
+	return target == ErrMissingConfig
+}
+
+// AuthError reports a failed login with an auth method.
+type AuthError struct {
+	// Method is the name of the auth method, such as "github" or "gcp".
+	Method string
+	Err    error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s auth: %v", e.Method, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes an AuthError match ErrAuthFailed.
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuthFailed
+}
+
+// ConflictError reports a check-and-set write that Vault rejected because the
+// secret changed after it was read.
+type ConflictError struct {
+	// Path is the path of the secret.
+	Path string
+	// Version is the version the write expected to replace.
+	Version int64
+	Err     error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("secret at %s is no longer at version %d: %v", e.Path, e.Version, e.Err)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a ConflictError match ErrConflict.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestConfigErrors(t *testing.T) {
+	t.Setenv("GITHUB_OAUTH_TOKEN", "")
+	t.Setenv("GCLOUD_PROJECT", "")
+
+	t.Run("no auth method", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := loadVaultEnvironment()
+		is.True(errors.Is(err, ErrNoAuthMethod))
+
+		var missing *MissingConfigError
+		is.True(errors.As(err, &missing))
+		is.Equal(missing.Name, "GCLOUD_PROJECT")
+
+		_, err = NewAuthClient(&config{})
+		is.True(errors.Is(err, ErrNoAuthMethod))
+	})
+
+	t.Run("missing variable", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("GCLOUD_PROJECT", "project")
+		t.Setenv("FUNCTION_IDENTITY", "")
+
+		_, err := loadVaultEnvironment()
+		is.True(errors.Is(err, ErrMissingConfig))
+		is.True(!errors.Is(err, ErrNoAuthMethod))
+
+		var missing *MissingConfigError
+		is.True(errors.As(err, &missing))
+		is.Equal(missing.Name, "FUNCTION_IDENTITY")
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/errors/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	opts := []Option{
+		WithAddress(cluster.Cores[0].Client.Address()),
+		WithTLSConfig(&api.TLSConfig{CACertBytes: cluster.CACertPEM}),
+	}
+
+	t.Run("auth failed", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := NewClient(context.Background(), append(opts, WithAppRoleAuth("", "role", "secret"))...)
+		is.True(errors.Is(err, ErrAuthFailed))
+
+		var authErr *AuthError
+		is.True(errors.As(err, &authErr))
+		is.Equal(authErr.Method, "approle")
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		is := is.New(t)
+
+		c := newTestClient(t, cluster)
+
+		secrets := map[string]map[string]string{}
+		err := c.GetSecrets(context.Background(), &secrets, []string{"kv/data/errors/missing"})
+		is.True(errors.Is(err, ErrSecretNotFound))
+
+		err = c.UpdateSecret(context.Background(), "kv/data/errors/missing", secretKey, secretValue)
+		is.True(errors.Is(err, ErrSecretNotFound))
+	})
+}
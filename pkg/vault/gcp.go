@@ -21,30 +21,39 @@ func NewGcpAuthClient() AuthClient {
 }
 
 func (a *gcpAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/gcp/GetVaultToken", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/gcp/GetVaultToken", vc.config.tracePrefix))()
 
+	vaultResp, err := a.login(vc)
+	if err != nil {
+		return "", err
+	}
+
+	return vaultResp.Auth.ClientToken, nil
+}
+
+func (a *gcpAuthClient) login(vc *vaultClient) (*api.Secret, error) {
 	var err error
 	a.credentialsClient, err = credentials.NewIamCredentialsClient(vc.ctx)
 	if err != nil {
-		return "", fmt.Errorf("getting new iam credentials client: %w", err)
+		return nil, &AuthError{Method: "gcp", Err: fmt.Errorf("getting new iam credentials client: %w", err)}
 	}
 
 	err = a.generateSignedJWT(vc)
 	if err != nil {
-		return "", fmt.Errorf("generate signed jwt:  %w", err)
+		return nil, &AuthError{Method: "gcp", Err: fmt.Errorf("generate signed jwt:  %w", err)}
 	}
 
 	vaultResp, err := a.gcpSaAuth(vc)
 	if err != nil {
-		return "", err
+		return nil, &AuthError{Method: "gcp", Err: err}
 	}
 
-	return vaultResp.Auth.ClientToken, nil
+	return vaultResp, nil
 }
 
 // generateSignedJWT returns a signed JWT response using IAM
 func (a *gcpAuthClient) generateSignedJWT(vc *vaultClient) error {
-	vc.tracer.trace(fmt.Sprintf("%s/gcp/generateSignedJWT", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/gcp/generateSignedJWT", vc.config.tracePrefix))()
 
 	// `projects/-/serviceAccounts/{ACCOUNT_EMAIL_OR_UNIQUEID}`. The `-` wildcard
 	// character is required; replacing it with a project ID is invalid.
@@ -76,9 +85,9 @@ func (a *gcpAuthClient) generateSignedJWT(vc *vaultClient) error {
 
 // gcpSaAuth takes signed JWT and sends login request to vault
 func (a *gcpAuthClient) gcpSaAuth(vc *vaultClient) (*api.Secret, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/gcp/vaultLogin", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/gcp/vaultLogin", vc.config.tracePrefix))()
 
-	vaultResp, err := vc.client.Logical().Write(
+	vaultResp, err := vc.client.Logical().WriteWithContext(vc.ctx,
 		"auth/"+vc.config.gcpAuthPath+"/login",
 		map[string]interface{}{
 			"role": vc.config.vaultRole,
@@ -89,5 +98,9 @@ func (a *gcpAuthClient) gcpSaAuth(vc *vaultClient) (*api.Secret, error) {
 		return nil, fmt.Errorf("logging into vault:%w", err)
 	}
 
+	if vaultResp == nil || vaultResp.Auth == nil {
+		return nil, fmt.Errorf("logging into vault: no auth info returned")
+	}
+
 	return vaultResp, nil
 }
@@ -14,9 +14,9 @@ func NewGithubAuthClient() AuthClient {
 	return &githubAuthClient{}
 }
 func (a *githubAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/github/GetVaultToken", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/github/GetVaultToken", vc.config.tracePrefix))()
 
-	vaultResp, err := a.githubVaultAuth(vc)
+	vaultResp, err := a.login(vc)
 	if err != nil {
 		return "", err
 	}
@@ -24,11 +24,20 @@ func (a *githubAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
 	return vaultResp.Auth.ClientToken, nil
 }
 
+func (a *githubAuthClient) login(vc *vaultClient) (*api.Secret, error) {
+	vaultResp, err := a.githubVaultAuth(vc)
+	if err != nil {
+		return nil, &AuthError{Method: "github", Err: err}
+	}
+
+	return vaultResp, nil
+}
+
 // githubVaultAuth takes GitHub access token and sends login request to vault
 func (a *githubAuthClient) githubVaultAuth(vc *vaultClient) (*api.Secret, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/github/vaultLogin", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/github/vaultLogin", vc.config.tracePrefix))()
 
-	vaultResp, err := vc.client.Logical().Write(
+	vaultResp, err := vc.client.Logical().WriteWithContext(vc.ctx,
 		"auth/github/login",
 		map[string]interface{}{
 			"token": vc.config.githubToken,
@@ -38,5 +47,9 @@ func (a *githubAuthClient) githubVaultAuth(vc *vaultClient) (*api.Secret, error)
 		return nil, fmt.Errorf("logging into vault with github:%w", err)
 	}
 
+	if vaultResp == nil || vaultResp.Auth == nil {
+		return nil, fmt.Errorf("logging into vault with github: no auth info returned")
+	}
+
 	return vaultResp, nil
 }
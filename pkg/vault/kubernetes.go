@@ -0,0 +1,68 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type kubernetesAuthClient struct {
+}
+
+// NewKubernetesAuthClient returns a new instance of an auth client
+func NewKubernetesAuthClient() AuthClient {
+	return &kubernetesAuthClient{}
+}
+
+func (a *kubernetesAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/kubernetes/GetVaultToken", vc.config.tracePrefix))()
+
+	vaultResp, err := a.login(vc)
+	if err != nil {
+		return "", err
+	}
+
+	return vaultResp.Auth.ClientToken, nil
+}
+
+func (a *kubernetesAuthClient) login(vc *vaultClient) (*api.Secret, error) {
+	vaultResp, err := a.kubernetesVaultAuth(vc)
+	if err != nil {
+		return nil, &AuthError{Method: "kubernetes", Err: err}
+	}
+
+	return vaultResp, nil
+}
+
+// kubernetesVaultAuth reads the pod's service account token and sends login request to vault
+func (a *kubernetesAuthClient) kubernetesVaultAuth(vc *vaultClient) (*api.Secret, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/kubernetes/vaultLogin", vc.config.tracePrefix))()
+
+	jwt := []byte(vc.config.k8sToken)
+	if len(jwt) == 0 {
+		var err error
+		jwt, err = os.ReadFile(vc.config.k8sTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading service account token: %w", err)
+		}
+	}
+
+	vaultResp, err := vc.client.Logical().WriteWithContext(vc.ctx,
+		"auth/"+vc.config.k8sAuthPath+"/login",
+		map[string]interface{}{
+			"role": vc.config.k8sRole,
+			"jwt":  strings.TrimSpace(string(jwt)),
+		})
+
+	if err != nil {
+		return nil, fmt.Errorf("logging into vault with kubernetes:%w", err)
+	}
+
+	if vaultResp == nil || vaultResp.Auth == nil {
+		return nil, fmt.Errorf("logging into vault with kubernetes: no auth info returned")
+	}
+
+	return vaultResp, nil
+}
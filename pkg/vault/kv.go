@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// fallbackMountTTL is how long a failed mount lookup is remembered, so paths
+// on a mount that can't be looked up don't cost an extra request every time,
+// while a lookup that starts to work later is still picked up.
+const fallbackMountTTL = 5 * time.Minute
+
+// kvMount describes the KV secrets engine a secret path is mounted on.
+type kvMount struct {
+	// path is the mount path with a trailing slash, e.g. "kv/". It is empty
+	// when the mount could not be looked up, in which case secret paths are
+	// used as given.
+	path    string
+	version int
+	// fallback is set when the lookup failed. The mount is then cached for the
+	// first segment of the path until expires, and secret paths are used as
+	// given.
+	fallback bool
+	expires  time.Time
+}
+
+func (m kvMount) isV2() bool {
+	return m.version == 2
+}
+
+// apiPath returns the API path of the secret at path. On KV v2 mounts that is
+// the "data" or "metadata" path, depending on kind, so both "kv/foo" and
+// "kv/data/foo" resolve to "kv/data/foo". On KV v1 mounts it is path itself.
+func (m kvMount) apiPath(path, kind string) string {
+	if !m.isV2() || m.fallback || m.path == "" || !strings.HasPrefix(path, m.path) {
+		return path
+	}
+
+	rel := strings.TrimPrefix(path, m.path)
+	for _, prefix := range []string{"data", "metadata"} {
+		if rel == prefix {
+			rel = ""
+			break
+		}
+
+		if strings.HasPrefix(rel, prefix+"/") {
+			rel = strings.TrimPrefix(rel, prefix+"/")
+			break
+		}
+	}
+
+	return m.path + kind + "/" + rel
+}
+
+// mountCache caches KV mounts by namespace and mount path, since the same
+// mount path can hold different engines in different namespaces.
+type mountCache struct {
+	mu     sync.RWMutex
+	mounts map[string]map[string]kvMount
+}
+
+func newMountCache() *mountCache {
+	return &mountCache{mounts: map[string]map[string]kvMount{}}
+}
+
+// get returns the mount cached for namespace with the longest path that
+// prefixes path.
+func (c *mountCache) get(namespace, path string) (kvMount, bool) {
+	if c == nil {
+		return kvMount{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var found kvMount
+	now := time.Now()
+	for mountPath, m := range c.mounts[namespace] {
+		if m.fallback && now.After(m.expires) {
+			continue
+		}
+
+		if strings.HasPrefix(path, mountPath) && len(mountPath) > len(found.path) {
+			found = m
+		}
+	}
+
+	return found, found.path != ""
+}
+
+func (c *mountCache) add(namespace string, m kvMount) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mounts[namespace] == nil {
+		c.mounts[namespace] = map[string]kvMount{}
+	}
+	c.mounts[namespace][m.path] = m
+}
+
+// kvMount returns the KV mount that path belongs to, looking it up in Vault
+// the first time a mount is seen. If the lookup fails, the path is treated as
+// a KV v2 API path, which is what the client expected before mounts were
+// looked up, and the failure is cached for fallbackMountTTL.
+func (vc *vaultClient) kvMount(path string) kvMount {
+	namespace := vc.client.Namespace()
+	if m, ok := vc.mounts.get(namespace, path); ok {
+		return m
+	}
+
+	resp, err := vc.client.Logical().ReadWithContext(vc.ctx, "sys/internal/ui/mounts/"+strings.TrimPrefix(path, "/"))
+	if err != nil || resp == nil || resp.Data == nil {
+		return vc.fallbackMount(namespace, path)
+	}
+
+	mountPath, _ := resp.Data["path"].(string)
+	if mountPath == "" {
+		return vc.fallbackMount(namespace, path)
+	}
+
+	m := kvMount{path: mountPath, version: 1}
+	if options, ok := resp.Data["options"].(map[string]interface{}); ok && options["version"] == "2" {
+		m.version = 2
+	}
+
+	vc.mounts.add(namespace, m)
+
+	return m
+}
+
+// fallbackMount caches and returns the KV v2 mount assumed for path when its
+// mount can't be looked up. As the mount path is unknown, it is cached for the
+// first segment of path.
+func (vc *vaultClient) fallbackMount(namespace, path string) kvMount {
+	m := kvMount{version: 2, fallback: true, expires: time.Now().Add(fallbackMountTTL)}
+
+	if segment, _, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/"); ok && segment != "" {
+		m.path = segment + "/"
+		vc.mounts.add(namespace, m)
+	}
+
+	return m
+}
@@ -0,0 +1,191 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestKVMountAPIPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		mount    kvMount
+		path     string
+		kind     string
+		expected string
+	}{
+		{"v2 secret path", kvMount{path: "kv/", version: 2}, "kv/foo/bar", "data", "kv/data/foo/bar"},
+		{"v2 data path", kvMount{path: "kv/", version: 2}, "kv/data/foo/bar", "data", "kv/data/foo/bar"},
+		{"fallback path", kvMount{path: "kv/", version: 2, fallback: true}, "kv/data/foo/bar", "metadata", "kv/data/foo/bar"},
+		{"v2 data path to metadata", kvMount{path: "kv/", version: 2}, "kv/data/foo/bar", "metadata", "kv/metadata/foo/bar"},
+		{"v2 metadata path", kvMount{path: "kv/", version: 2}, "kv/metadata/foo", "metadata", "kv/metadata/foo"},
+		{"v2 mount root", kvMount{path: "kv/", version: 2}, "kv/metadata", "metadata", "kv/metadata/"},
+		{"v2 nested mount", kvMount{path: "staging/applications/", version: 2}, "staging/applications/foo/dotenv", "data", "staging/applications/data/foo/dotenv"},
+		{"v1 path", kvMount{path: "kv1/", version: 1}, "kv1/foo", "data", "kv1/foo"},
+		{"unknown mount", kvMount{version: 2}, "kv/data/foo", "metadata", "kv/data/foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(tt.mount.apiPath(tt.path, tt.kind), tt.expected)
+		})
+	}
+}
+
+func TestKVVersions(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/versions/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	root := cluster.Cores[0].Client
+	if err := root.Sys().Mount("kv1", &api.MountInput{
+		Type:    "kv",
+		Options: map[string]string{"version": "1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := root.Logical().Write("kv1/versions/foo", map[string]interface{}{secretKey: secretValue}); err != nil {
+		t.Fatal(err)
+	}
+
+	vc := &vaultClient{
+		config: &config{},
+		ctx:    context.Background(),
+		client: root,
+		mounts: newMountCache(),
+	}
+	vc.tracer = vc
+
+	t.Run("kv v1 lifecycle", testKVLifecycle(vc, "kv1/versions/foo", "kv1/versions"))
+	t.Run("kv v2 lifecycle without data segment", testKVLifecycle(vc, "kv/versions/foo", "kv/versions"))
+	t.Run("kv v1 has no versions", testKVv1Versions(vc))
+	t.Run("kv v2 versions without metadata segment", testKVv2Versions(vc))
+	t.Run("mounts are cached", testKVMountsCached(vc))
+}
+
+func testKVLifecycle(vc *vaultClient, path, parent string) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		secret, err := vc.SecretFromVault(path)
+		is.NoErr(err)
+		is.Equal(secret, map[string]string{secretKey: secretValue})
+
+		_, err = vc.create(path, "new-key", "new-value")
+		is.NoErr(err)
+
+		_, err = vc.update(path, "new-key", "newer-value")
+		is.NoErr(err)
+
+		_, err = vc.delete(path, secretKey)
+		is.NoErr(err)
+
+		secret, err = vc.SecretFromVault(path)
+		is.NoErr(err)
+		is.Equal(secret, map[string]string{"new-key": "newer-value"})
+
+		engines, err := vc.enginesFromVault(parent)
+		is.NoErr(err)
+		is.Equal(engines, []string{"foo"})
+	}
+}
+
+func testKVv1Versions(vc *vaultClient) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := vc.SecretVersionFromVault("kv1/versions/foo")
+		is.True(err != nil)
+	}
+}
+
+func testKVv2Versions(vc *vaultClient) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		version, err := vc.SecretVersionFromVault("kv/versions/foo")
+		is.NoErr(err)
+		is.Equal(version, int64(4))
+	}
+}
+
+func testKVMountsCached(vc *vaultClient) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		m, ok := vc.mounts.get("", "kv1/anything")
+		is.True(ok)
+		is.Equal(m, kvMount{path: "kv1/", version: 1})
+
+		m, ok = vc.mounts.get("", "kv/anything")
+		is.True(ok)
+		is.Equal(m, kvMount{path: "kv/", version: 2})
+	}
+}
+
+func TestKVMountFallbackCached(t *testing.T) {
+	is := is.New(t)
+
+	// a token that may read secrets but not look up mounts
+	var lookups atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+		var data map[string]interface{}
+		switch {
+		case path == "auth/token/lookup-self":
+			data = map[string]interface{}{"ttl": 3600, "renewable": false}
+		case strings.HasPrefix(path, "sys/internal/ui/mounts/"):
+			lookups.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case strings.HasPrefix(path, "kv/data/"):
+			data = map[string]interface{}{
+				"data":     map[string]interface{}{"key": "value"},
+				"metadata": map[string]interface{}{"version": 1},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer s.Close()
+
+	c, err := NewClient(context.Background(),
+		WithAddress(s.URL),
+		WithAuthClient(&tokenAuthClient{token: "token"}),
+		WithRetry(0, 0, 0),
+	)
+	is.NoErr(err)
+
+	secrets := map[string]map[string]string{}
+	is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/a"}))
+	is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/a"}))
+	is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/b"}))
+	is.Equal(secrets["kv/data/b"], map[string]string{"key": "value"})
+	is.Equal(lookups.Load(), int32(1))
+
+	// the failure is only remembered for a while
+	m, ok := c.vc.mounts.get("", "kv/data/a")
+	is.True(ok)
+	is.True(m.fallback)
+
+	m.expires = time.Now().Add(-time.Second)
+	c.vc.mounts.add("", m)
+	is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/a"}))
+	is.Equal(lookups.Load(), int32(2))
+}
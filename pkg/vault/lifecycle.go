@@ -0,0 +1,89 @@
+package vault
+
+import (
+	"fmt"
+)
+
+// softDelete marks versions of a KV v2 secret as deleted, so they can no
+// longer be read but can still be undeleted. Without versions the latest
+// version is deleted. On KV v1, which has no versions, the secret itself is
+// deleted.
+func (vc *vaultClient) softDelete(secretName string, versions []int64) error {
+	defer vc.tracer.trace(fmt.Sprintf("%s/softDelete", vc.config.tracePrefix))()
+
+	mount := vc.kvMount(secretName)
+	if !mount.isV2() && len(versions) > 0 {
+		return fmt.Errorf("secret %s is on KV v1 mount %s, which has no versions", secretName, mount.path)
+	}
+
+	var err error
+	if len(versions) == 0 {
+		_, err = vc.client.Logical().DeleteWithContext(vc.ctx, mount.apiPath(secretName, "data"))
+	} else {
+		_, err = vc.client.Logical().WriteWithContext(vc.ctx, mount.apiPath(secretName, "delete"), map[string]interface{}{
+			"versions": versions,
+		})
+	}
+	vc.cache.invalidate(vc.cacheKey(secretName))
+	if err != nil {
+		return fmt.Errorf("failed to delete secret at %s: %w", secretName, err)
+	}
+
+	return nil
+}
+
+// undelete restores soft deleted versions of a KV v2 secret.
+func (vc *vaultClient) undelete(secretName string, versions []int64) error {
+	defer vc.tracer.trace(fmt.Sprintf("%s/undelete", vc.config.tracePrefix))()
+
+	return vc.writeVersions(secretName, "undelete", versions)
+}
+
+// destroy permanently removes the data of versions of a KV v2 secret.
+func (vc *vaultClient) destroy(secretName string, versions []int64) error {
+	defer vc.tracer.trace(fmt.Sprintf("%s/destroy", vc.config.tracePrefix))()
+
+	return vc.writeVersions(secretName, "destroy", versions)
+}
+
+// writeVersions sends versions to the undelete or destroy endpoint of a KV v2
+// secret.
+func (vc *vaultClient) writeVersions(secretName, kind string, versions []int64) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions to %s given for secret %s", kind, secretName)
+	}
+
+	mount := vc.kvMount(secretName)
+	if !mount.isV2() {
+		return fmt.Errorf("secret %s is on KV v1 mount %s, which has no versions", secretName, mount.path)
+	}
+
+	_, err := vc.client.Logical().WriteWithContext(vc.ctx, mount.apiPath(secretName, kind), map[string]interface{}{
+		"versions": versions,
+	})
+	vc.cache.invalidate(vc.cacheKey(secretName))
+	if err != nil {
+		return fmt.Errorf("failed to %s versions %v of %s: %w", kind, versions, secretName, err)
+	}
+
+	return nil
+}
+
+// deleteMetadata permanently removes a KV v2 secret with all its versions and
+// metadata.
+func (vc *vaultClient) deleteMetadata(secretName string) error {
+	defer vc.tracer.trace(fmt.Sprintf("%s/deleteMetadata", vc.config.tracePrefix))()
+
+	mount := vc.kvMount(secretName)
+	if !mount.isV2() {
+		return fmt.Errorf("secret %s is on KV v1 mount %s, which has no metadata", secretName, mount.path)
+	}
+
+	_, err := vc.client.Logical().DeleteWithContext(vc.ctx, mount.apiPath(secretName, "metadata"))
+	vc.cache.invalidate(vc.cacheKey(secretName))
+	if err != nil {
+		return fmt.Errorf("failed to delete metadata of %s: %w", secretName, err)
+	}
+
+	return nil
+}
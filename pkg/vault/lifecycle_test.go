@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSecretLifecycle(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/lifecycle/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	_, err := rootVaultClient.Logical().Write(secretEngine, map[string]interface{}{
+		"data": map[string]interface{}{secretKey: "second"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, cluster)
+
+	t.Run("delete latest version", testDeleteLatest(c))
+	t.Run("undelete versions", testUndelete(c))
+	t.Run("delete and destroy versions", testDestroy(c))
+	t.Run("undelete without versions", testUndeleteWithoutVersions(c))
+	t.Run("delete metadata", testDeleteMetadata(c))
+}
+
+func testDeleteLatest(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(c.DeleteSecretVersions(context.Background(), secretEngine))
+
+		_, err := c.GetSecretData(context.Background(), secretEngine)
+		is.True(errors.Is(err, ErrSecretNotFound))
+
+		data, err := c.GetSecretVersion(context.Background(), secretEngine, 1)
+		is.NoErr(err)
+		is.Equal(data[secretKey], secretValue)
+	}
+}
+
+func testUndelete(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(c.UndeleteSecretVersions(context.Background(), secretEngine, 2))
+
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data[secretKey], "second")
+	}
+}
+
+func testDestroy(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(c.DeleteSecretVersions(context.Background(), secretEngine, 1))
+		is.NoErr(c.DestroySecretVersions(context.Background(), secretEngine, 2))
+
+		metadata, err := c.GetSecretMetadata(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(len(metadata.Versions), 2)
+		is.True(!metadata.Versions[0].DeletionTime.IsZero())
+		is.True(!metadata.Versions[0].Destroyed)
+		is.True(metadata.Versions[1].Destroyed)
+
+		is.NoErr(c.UndeleteSecretVersions(context.Background(), secretEngine, 2))
+		_, err = c.GetSecretVersion(context.Background(), secretEngine, 2)
+		is.True(errors.Is(err, ErrSecretNotFound))
+	}
+}
+
+func testUndeleteWithoutVersions(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		err := c.UndeleteSecretVersions(context.Background(), secretEngine)
+		is.True(err != nil)
+	}
+}
+
+func testDeleteMetadata(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(c.DeleteSecretMetadata(context.Background(), secretEngine))
+
+		_, err := c.GetSecretMetadata(context.Background(), secretEngine)
+		is.True(errors.Is(err, ErrSecretNotFound))
+	}
+}
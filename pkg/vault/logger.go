@@ -0,0 +1,110 @@
+package vault
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// redacted replaces the values of sensitive log attributes.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are the attribute keys whose values are never logged. A key
+// matches when it, or its last dot separated part, is in the set, ignoring
+// case.
+var sensitiveKeys = map[string]bool{
+	"token":        true,
+	"client_token": true,
+	"accessor":     true,
+	"password":     true,
+	"secret":       true,
+	"secret_id":    true,
+	"role_id":      true,
+	"jwt":          true,
+	"private_key":  true,
+	"plaintext":    true,
+	"value":        true,
+	"values":       true,
+	"data":         true,
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+
+	return sensitiveKeys[key]
+}
+
+// redactHandler wraps a slog.Handler and redacts the values of sensitive
+// attributes, including those in groups and those added with With.
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redactedRecord := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redactedRecord.AddAttrs(redactAttr(a))
+		return true
+	})
+
+	return h.Handler.Handle(ctx, redactedRecord)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = redactAttr(a)
+	}
+
+	return redactHandler{h.Handler.WithAttrs(redactedAttrs)}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return a
+	}
+
+	group := a.Value.Group()
+	redactedGroup := make([]any, len(group))
+	for i, ga := range group {
+		redactedGroup[i] = redactAttr(ga)
+	}
+
+	return slog.Group(a.Key, redactedGroup...)
+}
+
+// discardLogger is used when no logger is set, so the package never writes
+// logs on its own.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+// newLogger returns logger with sensitive attributes redacted, or a logger
+// that discards everything if logger is nil.
+func newLogger(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+
+	return slog.New(redactHandler{logger.Handler()})
+}
+
+// logger returns the logger of the client.
+func (vc *vaultClient) logger() *slog.Logger {
+	if vc.log != nil {
+		return vc.log
+	}
+
+	return discardLogger
+}
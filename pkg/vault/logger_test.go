@@ -0,0 +1,54 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRedactHandler(t *testing.T) {
+	is := is.New(t)
+
+	var buf bytes.Buffer
+	logger := newLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	logger.With("secret_id", "s3cr3t-id").Info("message",
+		"path", "kv/data/app",
+		"Token", "s.token",
+		"db.password", "hunter2",
+		slog.Group("response", "data", map[string]string{"key": "v4lue"}, "version", 3),
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"s3cr3t-id", "s.token", "hunter2", "v4lue"} {
+		is.True(!strings.Contains(out, secret))
+	}
+
+	is.True(strings.Contains(out, `"path":"kv/data/app"`))
+	is.True(strings.Contains(out, `"version":3`))
+	is.Equal(strings.Count(out, redacted), 4)
+}
+
+func TestLogger(t *testing.T) {
+	is := is.New(t)
+
+	s := newNamespaceServer()
+	defer s.Close()
+
+	var buf bytes.Buffer
+	_, err := NewClient(context.Background(),
+		WithAddress(s.URL),
+		WithAuthClient(&tokenAuthClient{token: "s.supersecret"}),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+	is.NoErr(err)
+
+	out := buf.String()
+	is.True(strings.Contains(out, `"msg":"logged in to vault"`))
+	is.True(strings.Contains(out, `"method":"custom"`))
+	is.True(!strings.Contains(out, "s.supersecret"))
+}
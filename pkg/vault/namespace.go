@@ -0,0 +1,22 @@
+package vault
+
+import (
+	"context"
+)
+
+type namespaceKey struct{}
+
+// ContextWithNamespace returns a copy of ctx that makes the Client calls it is
+// passed to use namespace instead of the namespace set with WithNamespace or
+// VAULT_NAMESPACE. An empty namespace makes the calls use the root namespace.
+func ContextWithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// namespaceFromContext returns the namespace set on ctx with
+// ContextWithNamespace, if any.
+func namespaceFromContext(ctx context.Context) (string, bool) {
+	namespace, ok := ctx.Value(namespaceKey{}).(string)
+
+	return namespace, ok
+}
@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+// namespaceServer is a fake Vault that serves a KV v2 mount at kv/ and records
+// the namespace header of each request by path.
+type namespaceServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	namespaces map[string]string
+}
+
+func newNamespaceServer() *namespaceServer {
+	s := &namespaceServer{namespaces: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+		s.mu.Lock()
+		s.namespaces[path] = r.Header.Get(api.NamespaceHeaderName)
+		s.mu.Unlock()
+
+		var data map[string]interface{}
+		switch {
+		case path == "auth/token/lookup-self":
+			data = map[string]interface{}{"ttl": 3600, "renewable": false}
+		case strings.HasPrefix(path, "sys/internal/ui/mounts/"):
+			data = map[string]interface{}{"path": "kv/", "options": map[string]interface{}{"version": "2"}}
+		case strings.HasPrefix(path, "kv/metadata/"):
+			data = map[string]interface{}{"keys": []string{"foo"}}
+		case strings.HasPrefix(path, "kv/data/"):
+			data = map[string]interface{}{
+				"data":     map[string]interface{}{"key": "value"},
+				"metadata": map[string]interface{}{"version": 1},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+
+	return s
+}
+
+func (s *namespaceServer) namespace(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.namespaces[path]
+}
+
+func TestNamespace(t *testing.T) {
+	s := newNamespaceServer()
+	defer s.Close()
+
+	t.Setenv("VAULT_NAMESPACE", "env")
+
+	t.Run("from environment", testNamespaceFromEnvironment(s))
+	t.Run("option overrides environment", testNamespaceOption(s))
+	t.Run("per call override", testNamespaceContext(s))
+}
+
+func testNamespaceFromEnvironment(s *namespaceServer) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		c, err := NewClient(context.Background(),
+			WithAddress(s.URL),
+			WithAuthClient(&tokenAuthClient{token: "token"}),
+		)
+		is.NoErr(err)
+
+		is.Equal(s.namespace("auth/token/lookup-self"), "env")
+
+		_, err = c.ListEngines(context.Background(), "kv/metadata/env")
+		is.NoErr(err)
+		is.Equal(s.namespace("kv/metadata/env"), "env")
+	}
+}
+
+func testNamespaceOption(s *namespaceServer) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		c, err := NewClient(context.Background(),
+			WithAddress(s.URL),
+			WithAuthClient(&tokenAuthClient{token: "token"}),
+			WithNamespace("team"),
+		)
+		is.NoErr(err)
+
+		is.Equal(s.namespace("auth/token/lookup-self"), "team")
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/option"}))
+		is.Equal(secrets["kv/data/option"], map[string]string{"key": "value"})
+		is.Equal(s.namespace("kv/data/option"), "team")
+	}
+}
+
+func testNamespaceContext(s *namespaceServer) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		c, err := NewClient(context.Background(),
+			WithAddress(s.URL),
+			WithAuthClient(&tokenAuthClient{token: "token"}),
+			WithNamespace("team"),
+		)
+		is.NoErr(err)
+
+		ctx := ContextWithNamespace(context.Background(), "team/child")
+
+		_, err = c.ListEngines(ctx, "kv/metadata/context")
+		is.NoErr(err)
+		is.Equal(s.namespace("kv/metadata/context"), "team/child")
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(ctx, &secrets, []string{"kv/data/context/a", "kv/data/context/b"}))
+		is.Equal(s.namespace("kv/data/context/a"), "team/child")
+		is.Equal(s.namespace("kv/data/context/b"), "team/child")
+
+		// the override does not stick to the client
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/context/c"}))
+		is.Equal(s.namespace("kv/data/context/c"), "team")
+
+		// an empty namespace selects the root namespace
+		is.NoErr(c.GetSecrets(ContextWithNamespace(context.Background(), ""), &secrets, []string{"kv/data/context/d"}))
+		is.Equal(s.namespace("kv/data/context/d"), "")
+	}
+}
+
+func TestMountCacheNamespaces(t *testing.T) {
+	is := is.New(t)
+
+	c := newMountCache()
+	c.add("team", kvMount{path: "kv/", version: 2})
+
+	m, ok := c.get("team", "kv/foo")
+	is.True(ok)
+	is.Equal(m.version, 2)
+
+	_, ok = c.get("", "kv/foo")
+	is.True(!ok)
+}
@@ -0,0 +1,324 @@
+package vault
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures a Client built with NewClient.
+type Option func(*config)
+
+// WithAddress sets the address of the Vault server, overriding VAULT_ADDR.
+func WithAddress(address string) Option {
+	return func(c *config) {
+		c.address = address
+	}
+}
+
+// WithAuthClient sets the AuthClient used to log in to Vault.
+func WithAuthClient(a AuthClient) Option {
+	return func(c *config) {
+		c.authClient = a
+	}
+}
+
+// WithGithubAuth logs in to Vault with a GitHub personal access token.
+func WithGithubAuth(token string) Option {
+	return func(c *config) {
+		c.githubToken = token
+	}
+}
+
+// WithGCPAuth logs in to Vault with the GCP auth method, using a signed JWT
+// for the given service account and the given Vault role.
+func WithGCPAuth(project, serviceAccount, role string) Option {
+	return func(c *config) {
+		c.project = project
+		c.serviceAccount = serviceAccount
+		c.vaultRole = role
+		if c.gcpAuthPath == "" {
+			c.gcpAuthPath = "gcp"
+		}
+	}
+}
+
+// WithKubernetesAuth logs in to Vault with the kubernetes auth method mounted
+// at mount, using the service account token read from tokenPath and the given
+// Vault role. Empty mount and tokenPath default to "kubernetes" and the token
+// kubernetes mounts into every pod.
+func WithKubernetesAuth(mount, role, tokenPath string) Option {
+	return func(c *config) {
+		if mount == "" {
+			mount = "kubernetes"
+		}
+		if tokenPath == "" {
+			tokenPath = defaultK8sTokenPath
+		}
+
+		c.k8sAuthPath = mount
+		c.k8sRole = role
+		c.k8sTokenPath = tokenPath
+	}
+}
+
+// WithAppRoleAuth logs in to Vault with the AppRole auth method mounted at
+// mount. An empty mount defaults to "approle".
+func WithAppRoleAuth(mount, roleID, secretID string) Option {
+	return func(c *config) {
+		c.approle.authPath = mount
+		c.approle.roleID = roleID
+		c.approle.secretID = secretID
+		if c.approle.authPath == "" {
+			c.approle.authPath = "approle"
+		}
+	}
+}
+
+// WithAppRoleAuthFiles logs in to Vault with the AppRole auth method mounted
+// at mount, reading the role and secret IDs from files at every login. An
+// empty mount defaults to "approle".
+func WithAppRoleAuthFiles(mount, roleIDFile, secretIDFile string) Option {
+	return func(c *config) {
+		c.approle.authPath = mount
+		c.approle.roleIDFile = roleIDFile
+		c.approle.secretIDFile = secretIDFile
+		if c.approle.authPath == "" {
+			c.approle.authPath = "approle"
+		}
+	}
+}
+
+// WithWrappedSecretID treats the AppRole secret ID as a response-wrapping
+// token, which is unwrapped before logging in.
+func WithWrappedSecretID() Option {
+	return func(c *config) {
+		c.approle.wrapped = true
+	}
+}
+
+// WithNamespace sets the Vault namespace used for logins and all operations,
+// overriding VAULT_NAMESPACE. Single calls can use another namespace with
+// ContextWithNamespace.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithUnchangedEnvironment stops the client from replacing berglas references
+// in VAULT_ADDR and VAULT_NAMESPACE with the secrets they resolve to, so the
+// process environment is left as it is, like VAULT_KEEP_ENVIRONMENT does.
+func WithUnchangedEnvironment() Option {
+	return func(c *config) {
+		c.keepEnv = true
+	}
+}
+
+// WithTracing enables tracing with the global OpenTelemetry TracerProvider,
+// naming spans with the given prefix.
+func WithTracing(prefix string) Option {
+	return func(c *config) {
+		c.traceEnabled = true
+		c.tracePrefix = prefix
+	}
+}
+
+// WithTracerProvider enables tracing with the OpenTelemetry TracerProvider tp
+// instead of the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.traceEnabled = true
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider records the login and read latency and error metrics with
+// the OpenTelemetry MeterProvider mp instead of the global one.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// WithLogger makes the client log logins, token renewals, retries and
+// failures of background work to logger. Secret values, tokens and
+// credentials are redacted from the log attributes. Without it, the client
+// does not log.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to Vault. Settings
+// it leaves empty are taken from VAULT_CACERT, VAULT_CAPATH,
+// VAULT_CLIENT_CERT, VAULT_CLIENT_KEY, VAULT_TLS_SERVER_NAME and
+// VAULT_SKIP_VERIFY. It replaces the settings of the TLS options before it,
+// and a nil t clears them.
+func WithTLSConfig(t *api.TLSConfig) Option {
+	return func(c *config) {
+		if t == nil {
+			c.tlsConfig = nil
+			return
+		}
+
+		tc := *t
+		c.tlsConfig = &tc
+	}
+}
+
+// WithCACert verifies the certificate of the Vault server with the PEM encoded
+// CA certificates in file, overriding VAULT_CACERT and VAULT_CAPATH.
+func WithCACert(file string) Option {
+	return func(c *config) {
+		c.tls().CACert = file
+	}
+}
+
+// WithCAPath verifies the certificate of the Vault server with the PEM encoded
+// CA certificates in the files of dir, overriding VAULT_CACERT and
+// VAULT_CAPATH.
+func WithCAPath(dir string) Option {
+	return func(c *config) {
+		c.tls().CAPath = dir
+	}
+}
+
+// WithClientCert presents the PEM encoded certificate and private key in
+// certFile and keyFile to Vault, overriding VAULT_CLIENT_CERT and
+// VAULT_CLIENT_KEY. It is needed when the Vault listener requires mTLS, and
+// for WithCertAuth.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(c *config) {
+		t := c.tls()
+		t.ClientCert = certFile
+		t.ClientKey = keyFile
+	}
+}
+
+// WithTLSServerName sets the name the certificate of the Vault server is
+// verified against, and sent with SNI, overriding VAULT_TLS_SERVER_NAME.
+func WithTLSServerName(name string) Option {
+	return func(c *config) {
+		c.tls().TLSServerName = name
+	}
+}
+
+// WithTLSSkipVerify disables verification of the certificate of the Vault
+// server, like VAULT_SKIP_VERIFY. Only use it for testing.
+func WithTLSSkipVerify() Option {
+	return func(c *config) {
+		c.tls().Insecure = true
+	}
+}
+
+// WithCertAuth logs in to Vault with the TLS certificate auth method mounted
+// at mount, using the client certificate set with WithClientCert or
+// VAULT_CLIENT_CERT. role names the certificate role to log in with; when it
+// is empty, Vault picks the role that matches the certificate. An empty mount
+// defaults to "cert".
+func WithCertAuth(mount, role string) Option {
+	return func(c *config) {
+		if mount == "" {
+			mount = "cert"
+		}
+
+		c.certAuthPath = mount
+		c.certRole = role
+	}
+}
+
+// WithTokenRenewal starts a background watcher that renews the client token
+// before it expires, and logs in again when it can no longer be renewed. The
+// handlers are called for every renewal, login and failure. Call Close to
+// stop the watcher.
+func WithTokenRenewal(handlers ...func(TokenEvent)) Option {
+	return func(c *config) {
+		c.tokenRenewal = true
+		c.tokenHandlers = append(c.tokenHandlers, handlers...)
+	}
+}
+
+// WithTimeout bounds every Vault request, retries included, to timeout,
+// overriding VAULT_CLIENT_TIMEOUT. The default is 60 seconds; zero disables
+// the timeout. A deadline on the context passed to a call applies as well.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.request.timeout = timeout
+	}
+}
+
+// WithRetry retries Vault requests that fail with a 5xx or 429 status, or a
+// connection error, up to maxRetries times, overriding VAULT_MAX_RETRIES. The
+// waits between retries grow exponentially from minWait up to maxWait, unless
+// Vault sends a Retry-After header. The default is 2 retries waiting from
+// 500 milliseconds up to 10 seconds; zero retries disables retrying.
+func WithRetry(maxRetries int, minWait, maxWait time.Duration) Option {
+	return func(c *config) {
+		c.request.maxRetries = maxRetries
+		c.request.minRetryWait = minWait
+		c.request.maxRetryWait = maxWait
+	}
+}
+
+// WithRateLimit limits the client to requestsPerSecond requests to Vault,
+// allowing bursts of up to burst requests, overriding VAULT_RATE_LIMIT. A burst
+// of zero defaults to the rate. Requests wait for the limiter, so the limit
+// applies to concurrent calls sharing the client as well.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *config) {
+		c.request.rateLimit = requestsPerSecond
+		c.request.rateBurst = burst
+	}
+}
+
+// WithConflictRetry retries CreateSecret, UpdateSecret and DeleteSecret up to
+// retries times when the secret is changed by someone else between reading and
+// writing it. The first retry waits for backoff, and every following retry
+// waits twice as long as the one before, up to 10 seconds.
+func WithConflictRetry(retries int, backoff time.Duration) Option {
+	return func(c *config) {
+		c.conflictRetries = retries
+		c.conflictBackoff = backoff
+	}
+}
+
+// WithConcurrency sets how many secrets GetSecrets reads at the same time.
+// The default is 4.
+func WithConcurrency(workers int) Option {
+	return func(c *config) {
+		c.concurrency = workers
+	}
+}
+
+// WithCollectErrors makes GetSecrets read every secret even when some of them
+// fail, and return the errors of all failed secrets joined together instead
+// of only the first one.
+func WithCollectErrors() Option {
+	return func(c *config) {
+		c.collectErrors = true
+	}
+}
+
+// WithCache caches the data of secrets read with GetSecrets, GetSecretData,
+// GetSecretResults and DecodeSecret for ttl. Writes through the client remove
+// the secrets they change from the cache.
+func WithCache(ttl time.Duration) Option {
+	return func(c *config) {
+		c.cacheTTL = ttl
+	}
+}
+
+// WithCacheRevalidation makes the cache check the current version of a KV v2
+// secret once its entry expires, and keep serving the cached data for another
+// TTL if the version has not changed. Checking the version is cheaper than
+// reading the data when secrets are large.
+func WithCacheRevalidation() Option {
+	return func(c *config) {
+		c.cacheRevalidate = true
+	}
+}
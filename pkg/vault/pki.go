@@ -0,0 +1,315 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CertificateRequest describes the certificate to issue from a PKI role.
+type CertificateRequest struct {
+	CommonName string
+	AltNames   []string
+	IPSANs     []string
+	// TTL is the requested lifetime. The role's default TTL is used if it is
+	// zero.
+	TTL time.Duration
+}
+
+// Certificate is a certificate issued by a PKI secrets engine, with its
+// private key. All certificates and keys are PEM encoded.
+type Certificate struct {
+	Certificate  string
+	PrivateKey   string
+	IssuingCA    string
+	CAChain      []string
+	SerialNumber string
+	Expiration   time.Time
+}
+
+// WriteFiles writes the certificate, private key and CA chain to the given
+// files. Each file is written to a temporary file first and renamed into
+// place, so readers never see a partial file. The private key is only
+// readable by its owner. Empty paths are skipped.
+func (c *Certificate) WriteFiles(certFile, keyFile, caFile string) error {
+	files := []struct {
+		path     string
+		contents string
+		perm     os.FileMode
+	}{
+		{certFile, c.Certificate + "\n", 0o644},
+		{keyFile, c.PrivateKey + "\n", 0o600},
+		{caFile, strings.Join(c.caChain(), "\n") + "\n", 0o644},
+	}
+
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+
+		if err := writeFileAtomic(f.path, []byte(f.contents), f.perm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// caChain returns the CA chain, or the issuing CA if Vault returned no chain.
+func (c *Certificate) caChain() []string {
+	if len(c.CAChain) > 0 {
+		return c.CAChain
+	}
+
+	return []string{c.IssuingCA}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// to path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// issueCertificate issues a certificate from role on the PKI secrets engine
+// mounted at mount.
+func (vc *vaultClient) issueCertificate(mount, role string, req CertificateRequest) (*Certificate, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/issueCertificate", vc.config.tracePrefix))()
+
+	if mount == "" {
+		mount = "pki"
+	}
+
+	data := map[string]interface{}{
+		"common_name": req.CommonName,
+	}
+	if len(req.AltNames) > 0 {
+		data["alt_names"] = strings.Join(req.AltNames, ",")
+	}
+	if len(req.IPSANs) > 0 {
+		data["ip_sans"] = strings.Join(req.IPSANs, ",")
+	}
+	if req.TTL > 0 {
+		data["ttl"] = req.TTL.String()
+	}
+
+	path := fmt.Sprintf("%s/issue/%s", mount, role)
+	secret, err := vc.client.Logical().WriteWithContext(vc.ctx, path, data)
+	if err != nil {
+		return nil, fmt.Errorf("issuing certificate from %s: %w", path, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no certificate returned from %s", path)
+	}
+
+	cert := &Certificate{}
+	cert.Certificate, _ = secret.Data["certificate"].(string)
+	cert.PrivateKey, _ = secret.Data["private_key"].(string)
+	cert.IssuingCA, _ = secret.Data["issuing_ca"].(string)
+	cert.SerialNumber, _ = secret.Data["serial_number"].(string)
+	if chain, ok := secret.Data["ca_chain"].([]interface{}); ok {
+		for _, c := range chain {
+			if s, ok := c.(string); ok {
+				cert.CAChain = append(cert.CAChain, s)
+			}
+		}
+	}
+	if expiration, ok := secret.Data["expiration"].(json.Number); ok {
+		if unix, err := expiration.Int64(); err == nil {
+			cert.Expiration = time.Unix(unix, 0)
+		}
+	}
+
+	if cert.Certificate == "" || cert.PrivateKey == "" {
+		return nil, fmt.Errorf("no certificate or private key returned from %s", path)
+	}
+
+	return cert, nil
+}
+
+// CertificateEventType identifies the kind of a CertificateEvent.
+type CertificateEventType int
+
+const (
+	// CertificateIssued is sent after a new certificate has been issued to
+	// replace the current one.
+	CertificateIssued CertificateEventType = iota
+	// CertificateFailed is sent when issuing a new certificate fails.
+	CertificateFailed
+)
+
+// CertificateEvent reports the re-issue of a CertificateRenewer certificate.
+type CertificateEvent struct {
+	Type CertificateEventType
+	// Certificate is the new certificate. It is nil for CertificateFailed.
+	Certificate *Certificate
+	// Err is set for CertificateFailed.
+	Err error
+}
+
+// defaultRenewFraction is the fraction of a certificate's lifetime after
+// which CertificateRenewer issues a new one when no fraction is given.
+const defaultRenewFraction = 2.0 / 3
+
+// CertificateRenewer holds a certificate issued by a PKI secrets engine and
+// issues a new one when a fraction of its lifetime has passed, until Close is
+// called.
+type CertificateRenewer struct {
+	vc       *vaultClient
+	mount    string
+	role     string
+	req      CertificateRequest
+	fraction float64
+	handlers []func(CertificateEvent)
+	retry    time.Duration
+
+	mu   sync.RWMutex
+	cert *Certificate
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Certificate returns the current certificate.
+func (r *CertificateRenewer) Certificate() *Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert
+}
+
+// Close stops issuing new certificates. The current certificate stays valid
+// until it expires. Calling Close again does nothing.
+func (r *CertificateRenewer) Close() {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+func (r *CertificateRenewer) run(issued time.Time) {
+	defer close(r.done)
+
+	for {
+		lifetime := r.Certificate().Expiration.Sub(issued)
+		if lifetime <= 0 {
+			// the certificate has no known expiry
+			<-r.stop
+			return
+		}
+
+		if !r.wait(time.Duration(float64(lifetime) * r.fraction)) {
+			return
+		}
+
+		for {
+			cert, err := r.vc.issueCertificate(r.mount, r.role, r.req)
+			if err == nil {
+				issued = time.Now()
+				r.mu.Lock()
+				r.cert = cert
+				r.mu.Unlock()
+
+				r.notify(CertificateEvent{Type: CertificateIssued, Certificate: cert})
+				break
+			}
+
+			r.notify(CertificateEvent{Type: CertificateFailed, Err: err})
+
+			if !r.wait(r.retry) {
+				return
+			}
+		}
+	}
+}
+
+// wait blocks for d, returning false if the renewer was closed first.
+func (r *CertificateRenewer) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-r.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (r *CertificateRenewer) notify(e CertificateEvent) {
+	switch e.Type {
+	case CertificateIssued:
+		r.vc.logger().Info("issued new certificate", "role", r.role, "serial_number", e.Certificate.SerialNumber, "expiration", e.Certificate.Expiration)
+	case CertificateFailed:
+		r.vc.logger().Warn("issuing new certificate failed", "role", r.role, "error", e.Err)
+	}
+
+	for _, handler := range r.handlers {
+		handler(e)
+	}
+}
+
+// newCertificateRenewer issues a certificate and starts re-issuing it.
+func (vc *vaultClient) newCertificateRenewer(mount, role string, req CertificateRequest, fraction float64, handlers []func(CertificateEvent)) (*CertificateRenewer, error) {
+	if fraction <= 0 || fraction >= 1 {
+		fraction = defaultRenewFraction
+	}
+
+	r := &CertificateRenewer{
+		vc:       vc.withContext(context.WithoutCancel(vc.ctx)),
+		mount:    mount,
+		role:     role,
+		req:      req,
+		fraction: fraction,
+		handlers: handlers,
+		retry:    defaultReloginInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	issued := time.Now()
+	cert, err := r.vc.issueCertificate(mount, role, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cert = cert
+	go r.run(issued)
+
+	return r, nil
+}
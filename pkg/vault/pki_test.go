@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestPKI(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/pki/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	if err := rootVaultClient.Sys().Mount("pki", &api.MountInput{
+		Type:   "pki",
+		Config: api.MountConfigInput{MaxLeaseTTL: "87600h"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rootVaultClient.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "example.com",
+		"ttl":         "8760h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rootVaultClient.Logical().Write("pki/roles/app", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"max_ttl":          "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, cluster)
+
+	t.Run("issue certificate", testIssueCertificate(c))
+	t.Run("write files", testWriteCertificateFiles(c))
+	t.Run("renew certificate", testCertificateRenewer(c))
+	t.Run("role not allowed", testIssueCertificateNotAllowed(c))
+}
+
+func testIssueCertificate(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		cert, err := c.IssueCertificate(context.Background(), "", "app", CertificateRequest{
+			CommonName: "api.example.com",
+			AltNames:   []string{"www.example.com"},
+			TTL:        time.Hour,
+		})
+		is.NoErr(err)
+		is.True(cert.SerialNumber != "")
+		is.True(len(cert.CAChain) > 0)
+		is.True(time.Until(cert.Expiration) > 59*time.Minute)
+
+		_, err = tls.X509KeyPair([]byte(cert.Certificate), []byte(cert.PrivateKey))
+		is.NoErr(err)
+
+		block, _ := pem.Decode([]byte(cert.Certificate))
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		is.NoErr(err)
+		is.Equal(leaf.Subject.CommonName, "api.example.com")
+		is.Equal(leaf.DNSNames, []string{"api.example.com", "www.example.com"})
+	}
+}
+
+func testWriteCertificateFiles(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		cert, err := c.IssueCertificate(context.Background(), "pki", "app", CertificateRequest{CommonName: "api.example.com"})
+		is.NoErr(err)
+
+		dir := t.TempDir()
+		certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+		is.NoErr(cert.WriteFiles(certFile, keyFile, caFile))
+
+		_, err = tls.LoadX509KeyPair(certFile, keyFile)
+		is.NoErr(err)
+
+		info, err := os.Stat(keyFile)
+		is.NoErr(err)
+		is.Equal(info.Mode().Perm(), os.FileMode(0o600))
+
+		ca, err := os.ReadFile(caFile)
+		is.NoErr(err)
+		is.True(x509.NewCertPool().AppendCertsFromPEM(ca))
+
+		entries, err := os.ReadDir(dir)
+		is.NoErr(err)
+		is.Equal(len(entries), 3)
+	}
+}
+
+func testCertificateRenewer(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		events := make(chan CertificateEvent, 10)
+		r, err := c.NewCertificateRenewer(context.Background(), "", "app",
+			CertificateRequest{CommonName: "api.example.com", TTL: 4 * time.Second},
+			0.25,
+			func(e CertificateEvent) { events <- e },
+		)
+		is.NoErr(err)
+		defer r.Close()
+
+		first := r.Certificate()
+
+		select {
+		case e := <-events:
+			is.NoErr(e.Err)
+			is.Equal(e.Type, CertificateIssued)
+			is.True(e.Certificate.SerialNumber != first.SerialNumber)
+			is.Equal(r.Certificate(), e.Certificate)
+		case <-time.After(10 * time.Second):
+			t.Fatal("no certificate issued")
+		}
+
+		// the deferred Close runs on a closed renewer
+		r.Close()
+	}
+}
+
+func testIssueCertificateNotAllowed(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := c.IssueCertificate(context.Background(), "", "app", CertificateRequest{CommonName: "evil.com"})
+		is.True(err != nil)
+	}
+}
@@ -0,0 +1,178 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// TokenEventType identifies the kind of a TokenEvent.
+type TokenEventType int
+
+const (
+	// TokenRenewed is sent after the token lease has been renewed.
+	TokenRenewed TokenEventType = iota
+	// TokenRelogin is sent after logging in again because the token could
+	// not be renewed any further.
+	TokenRelogin
+	// TokenFailed is sent when renewing the token or logging in again fails.
+	TokenFailed
+)
+
+// TokenEvent reports a change in the lifetime of a Client's Vault token.
+type TokenEvent struct {
+	Type TokenEventType
+	// Secret is the renewal or login response. It is nil for TokenFailed.
+	Secret *api.Secret
+	// Err is set for TokenFailed.
+	Err error
+}
+
+// defaultReloginInterval is how long the renewer waits before trying to log
+// in again after a failed login.
+const defaultReloginInterval = 10 * time.Second
+
+// tokenRenewer keeps the token of a vaultClient alive, renewing its lease and
+// logging in again through the AuthClient when it can no longer be renewed.
+type tokenRenewer struct {
+	vc       *vaultClient
+	handlers []func(TokenEvent)
+	retry    time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newTokenRenewer(vc *vaultClient, handlers []func(TokenEvent)) *tokenRenewer {
+	return &tokenRenewer{
+		vc:       vc.withContext(context.WithoutCancel(vc.ctx)),
+		handlers: handlers,
+		retry:    defaultReloginInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// start watches the given login response until close is called.
+func (r *tokenRenewer) start(secret *api.Secret) {
+	go r.run(secret)
+}
+
+// close stops the renewer and waits for it to exit.
+func (r *tokenRenewer) close() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *tokenRenewer) run(secret *api.Secret) {
+	defer close(r.done)
+
+	for {
+		switch {
+		case isRenewable(secret):
+			if !r.watch(secret) {
+				return
+			}
+		case tokenTTL(secret) > 0:
+			// log in again shortly before a non-renewable token expires
+			if !r.wait(tokenTTL(secret) * 9 / 10) {
+				return
+			}
+		default:
+			// the token never expires
+			<-r.stop
+			return
+		}
+
+		var err error
+		for secret, err = r.relogin(); err != nil; secret, err = r.relogin() {
+			r.notify(TokenEvent{Type: TokenFailed, Err: err})
+
+			if !r.wait(r.retry) {
+				return
+			}
+		}
+	}
+}
+
+// watch renews secret until it can no longer be renewed. It returns false if
+// the renewer was stopped.
+func (r *tokenRenewer) watch(secret *api.Secret) bool {
+	watcher, err := r.vc.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		r.notify(TokenEvent{Type: TokenFailed, Err: fmt.Errorf("starting token watcher: %w", err)})
+		return true
+	}
+
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return false
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				r.notify(TokenEvent{Type: TokenFailed, Err: fmt.Errorf("renewing token: %w", err)})
+			}
+
+			return true
+		case renewal := <-watcher.RenewCh():
+			r.notify(TokenEvent{Type: TokenRenewed, Secret: renewal.Secret})
+		}
+	}
+}
+
+// wait blocks for d, returning false if the renewer was stopped first.
+func (r *tokenRenewer) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-r.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (r *tokenRenewer) relogin() (*api.Secret, error) {
+	secret, err := r.vc.login()
+	if err != nil {
+		return nil, fmt.Errorf("logging in again: %w", err)
+	}
+
+	r.notify(TokenEvent{Type: TokenRelogin, Secret: secret})
+
+	return secret, nil
+}
+
+func (r *tokenRenewer) notify(e TokenEvent) {
+	switch e.Type {
+	case TokenRenewed:
+		r.vc.logger().Debug("renewed vault token", "ttl", tokenTTL(e.Secret))
+	case TokenRelogin:
+		r.vc.logger().Info("logged in to vault again", "ttl", tokenTTL(e.Secret))
+	case TokenFailed:
+		r.vc.logger().Warn("keeping vault token alive failed", "error", e.Err)
+	}
+
+	for _, handler := range r.handlers {
+		handler(e)
+	}
+}
+
+// isRenewable reports whether the token in a login response can be renewed.
+func isRenewable(secret *api.Secret) bool {
+	return secret.Auth != nil && secret.Auth.Renewable
+}
+
+// tokenTTL returns the lifetime of the token in a login response.
+func tokenTTL(secret *api.Secret) time.Duration {
+	if secret.Auth == nil {
+		return 0
+	}
+
+	return time.Duration(secret.Auth.LeaseDuration) * time.Second
+}
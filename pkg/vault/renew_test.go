@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+type shortLivedAuthClient struct {
+	root *api.Client
+}
+
+func (a *shortLivedAuthClient) GetVaultToken(vc *vaultClient) (string, error) {
+	secret, err := a.root.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies:       []string{"root"},
+		TTL:            "2s",
+		ExplicitMaxTTL: "4s",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return secret.Auth.ClientToken, nil
+}
+
+func TestTokenRenewal(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/renew/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	events := make(chan TokenEvent, 100)
+	c := newTestClient(t, cluster,
+		WithAuthClient(&shortLivedAuthClient{root: cluster.Cores[0].Client}),
+		WithTokenRenewal(func(e TokenEvent) { events <- e }),
+	)
+	defer c.Close()
+
+	t.Run("renews and logs in again", testRenewAndRelogin(c, events))
+}
+
+func testRenewAndRelogin(c *Client, events chan TokenEvent) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		seen := map[TokenEventType]bool{}
+		timeout := time.After(20 * time.Second)
+		for !seen[TokenRenewed] || !seen[TokenRelogin] {
+			select {
+			case e := <-events:
+				is.NoErr(e.Err)
+				seen[e.Type] = true
+			case <-timeout:
+				t.Fatalf("timed out waiting for token events, saw %v", seen)
+			}
+		}
+
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+		is.Equal(secrets[secretEngine][secretKey], secretValue)
+	}
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/vault/api"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultTimeout bounds every Vault request, retries included.
+	defaultTimeout = 60 * time.Second
+	// defaultMaxRetries is how many times a request failing with a 5xx or 429
+	// status is retried.
+	defaultMaxRetries = 2
+	// defaultMinRetryWait and defaultMaxRetryWait bound the exponential
+	// backoff between retries.
+	defaultMinRetryWait = 500 * time.Millisecond
+	defaultMaxRetryWait = 10 * time.Second
+)
+
+// requestConfig controls the timeout, retries and rate limit of Vault requests.
+type requestConfig struct {
+	timeout      time.Duration
+	maxRetries   int
+	minRetryWait time.Duration
+	maxRetryWait time.Duration
+	rateLimit    float64
+	rateBurst    int
+}
+
+// defaultRequestConfig returns the default request settings.
+func defaultRequestConfig() requestConfig {
+	return requestConfig{
+		timeout:      defaultTimeout,
+		maxRetries:   defaultMaxRetries,
+		minRetryWait: defaultMinRetryWait,
+		maxRetryWait: defaultMaxRetryWait,
+	}
+}
+
+// loadRequestEnvironment returns the default request settings, overridden by
+// the standard VAULT_CLIENT_TIMEOUT, VAULT_MAX_RETRIES and VAULT_RATE_LIMIT
+// variables.
+func loadRequestEnvironment() (requestConfig, error) {
+	r := defaultRequestConfig()
+
+	if v := getEnv("VAULT_CLIENT_TIMEOUT", ""); v != "" {
+		timeout, err := parseSeconds(v)
+		if err != nil {
+			return r, fmt.Errorf("parsing VAULT_CLIENT_TIMEOUT: %w", err)
+		}
+
+		r.timeout = timeout
+	}
+
+	if v := getEnv("VAULT_MAX_RETRIES", ""); v != "" {
+		retries, err := strconv.Atoi(v)
+		if err != nil {
+			return r, fmt.Errorf("parsing VAULT_MAX_RETRIES: %w", err)
+		}
+
+		r.maxRetries = retries
+	}
+
+	// VAULT_RATE_LIMIT is "rate" or "rate:burst", in requests per second
+	if v := getEnv("VAULT_RATE_LIMIT", ""); v != "" {
+		limit, burst, _ := strings.Cut(v, ":")
+
+		var err error
+		r.rateLimit, err = strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return r, fmt.Errorf("parsing VAULT_RATE_LIMIT: %w", err)
+		}
+
+		if burst != "" {
+			r.rateBurst, err = strconv.Atoi(burst)
+			if err != nil {
+				return r, fmt.Errorf("parsing VAULT_RATE_LIMIT: %w", err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// parseSeconds parses a duration like "30s", or a plain number of seconds.
+func parseSeconds(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// configure applies the request settings to apiConfig. Requests failing with
+// a 5xx or 429 status, or a connection error, are retried with exponential
+// backoff, honouring the Retry-After header of the response.
+func (r requestConfig) configure(apiConfig *api.Config) {
+	apiConfig.Timeout = r.timeout
+	apiConfig.MaxRetries = r.maxRetries
+	apiConfig.MinRetryWait = r.minRetryWait
+	apiConfig.MaxRetryWait = r.maxRetryWait
+	apiConfig.Backoff = retryablehttp.DefaultBackoff
+	apiConfig.CheckRetry = api.DefaultRetryPolicy
+
+	if r.rateLimit > 0 {
+		burst := r.rateBurst
+		if burst <= 0 {
+			burst = max(1, int(r.rateLimit))
+		}
+
+		apiConfig.Limiter = rate.NewLimiter(rate.Limit(r.rateLimit), burst)
+	}
+}
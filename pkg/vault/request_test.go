@@ -0,0 +1,200 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// flakyServer is a fake Vault whose secret reads fail with status for the
+// first failures requests and block for delay.
+type flakyServer struct {
+	*httptest.Server
+
+	status   int
+	failures int32
+	delay    time.Duration
+	reads    atomic.Int32
+}
+
+func newFlakyServer(status int, failures int32, delay time.Duration) *flakyServer {
+	s := &flakyServer{status: status, failures: failures, delay: delay}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+		var data map[string]interface{}
+		switch {
+		case path == "auth/token/lookup-self":
+			data = map[string]interface{}{"ttl": 3600, "renewable": false}
+		case strings.HasPrefix(path, "sys/internal/ui/mounts/"):
+			data = map[string]interface{}{"path": "kv/", "options": map[string]interface{}{"version": "2"}}
+		case strings.HasPrefix(path, "kv/data/"):
+			if s.reads.Add(1) <= s.failures {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(s.status)
+				return
+			}
+
+			select {
+			case <-time.After(s.delay):
+			case <-r.Context().Done():
+				return
+			}
+
+			data = map[string]interface{}{
+				"data":     map[string]interface{}{"key": "value"},
+				"metadata": map[string]interface{}{"version": 1},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+
+	return s
+}
+
+func TestRequestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		failures int32
+		retries  int
+		reads    int32
+		fails    bool
+	}{
+		{"retries unavailable", http.StatusServiceUnavailable, 2, 2, 3, false},
+		{"retries too many requests", http.StatusTooManyRequests, 1, 2, 2, false},
+		{"gives up after retries", http.StatusInternalServerError, 5, 1, 2, true},
+		{"no retry on client error", http.StatusForbidden, 1, 2, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			s := newFlakyServer(tt.status, tt.failures, 0)
+			defer s.Close()
+
+			c, err := NewClient(context.Background(),
+				WithAddress(s.URL),
+				WithAuthClient(&tokenAuthClient{token: "token"}),
+				WithRetry(tt.retries, time.Millisecond, 10*time.Millisecond),
+			)
+			is.NoErr(err)
+
+			secrets := map[string]map[string]string{}
+			err = c.GetSecrets(context.Background(), &secrets, []string{"kv/data/foo"})
+			is.Equal(err != nil, tt.fails)
+			is.Equal(s.reads.Load(), tt.reads)
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	is := is.New(t)
+
+	s := newFlakyServer(0, 0, 5*time.Second)
+	defer s.Close()
+
+	c, err := NewClient(context.Background(),
+		WithAddress(s.URL),
+		WithAuthClient(&tokenAuthClient{token: "token"}),
+		WithTimeout(50*time.Millisecond),
+		WithRetry(0, 0, 0),
+	)
+	is.NoErr(err)
+
+	start := time.Now()
+	secrets := map[string]map[string]string{}
+	err = c.GetSecrets(context.Background(), &secrets, []string{"kv/data/foo"})
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.True(time.Since(start) < time.Second)
+
+	// a deadline on the context applies as well
+	c, err = NewClient(context.Background(),
+		WithAddress(s.URL),
+		WithAuthClient(&tokenAuthClient{token: "token"}),
+	)
+	is.NoErr(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	err = c.GetSecrets(ctx, &secrets, []string{"kv/data/foo"})
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.True(time.Since(start) < time.Second)
+}
+
+func TestRequestRateLimit(t *testing.T) {
+	is := is.New(t)
+
+	s := newFlakyServer(0, 0, 0)
+	defer s.Close()
+
+	c, err := NewClient(context.Background(),
+		WithAddress(s.URL),
+		WithAuthClient(&tokenAuthClient{token: "token"}),
+		WithRateLimit(20, 1),
+	)
+	is.NoErr(err)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		secrets := map[string]map[string]string{}
+		is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/foo"}))
+	}
+
+	// the login and mount lookup used up the burst, so the five reads wait
+	// for 50ms each
+	is.True(time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestLoadRequestEnvironment(t *testing.T) {
+	is := is.New(t)
+
+	t.Setenv("VAULT_CLIENT_TIMEOUT", "")
+	t.Setenv("VAULT_MAX_RETRIES", "")
+	t.Setenv("VAULT_RATE_LIMIT", "")
+
+	r, err := loadRequestEnvironment()
+	is.NoErr(err)
+	is.Equal(r, requestConfig{
+		timeout:      defaultTimeout,
+		maxRetries:   defaultMaxRetries,
+		minRetryWait: defaultMinRetryWait,
+		maxRetryWait: defaultMaxRetryWait,
+	})
+
+	t.Setenv("VAULT_CLIENT_TIMEOUT", "5")
+	t.Setenv("VAULT_MAX_RETRIES", "4")
+	t.Setenv("VAULT_RATE_LIMIT", "10.5:3")
+
+	r, err = loadRequestEnvironment()
+	is.NoErr(err)
+	is.Equal(r.timeout, 5*time.Second)
+	is.Equal(r.maxRetries, 4)
+	is.Equal(r.rateLimit, 10.5)
+	is.Equal(r.rateBurst, 3)
+
+	t.Setenv("VAULT_CLIENT_TIMEOUT", "1m30s")
+	r, err = loadRequestEnvironment()
+	is.NoErr(err)
+	is.Equal(r.timeout, 90*time.Second)
+
+	t.Setenv("VAULT_RATE_LIMIT", "fast")
+	_, err = loadRequestEnvironment()
+	is.True(err != nil)
+}
@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SecretResult is the outcome of reading one secret with GetSecretResults.
+type SecretResult struct {
+	Path string
+	// Optional is set for paths that were allowed to fail.
+	Optional bool
+	Data     map[string]string
+	// Version is the version of the secret that was read. It is 0 on KV v1.
+	Version int64
+	Err     error
+}
+
+// OK reports whether the secret was read.
+func (r SecretResult) OK() bool {
+	return r.Err == nil
+}
+
+// SecretResults holds the result of every path passed to GetSecretResults, in
+// the order they were passed in.
+type SecretResults []SecretResult
+
+// Get returns the result for path.
+func (rs SecretResults) Get(path string) (SecretResult, bool) {
+	for _, r := range rs {
+		if r.Path == path {
+			return r, true
+		}
+	}
+
+	return SecretResult{}, false
+}
+
+// Values returns the data of every secret that was read, keyed by path, in the
+// same shape GetSecrets fills in.
+func (rs SecretResults) Values() map[string]map[string]string {
+	values := make(map[string]map[string]string, len(rs))
+	for _, r := range rs {
+		if r.OK() {
+			values[r.Path] = r.Data
+		}
+	}
+
+	return values
+}
+
+// Err returns the errors of the paths that failed and were not optional,
+// joined together, or nil if all of them were read.
+func (rs SecretResults) Err() error {
+	var errs []error
+	for _, r := range rs {
+		if !r.OK() && !r.Optional {
+			errs = append(errs, r.Err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// secretResults reads every secret, both required and optional, and reports
+// the outcome of each.
+func (vc *vaultClient) secretResults(secretNames, optionalNames []string) SecretResults {
+	paths := append(append([]string{}, secretNames...), optionalNames...)
+	fetched := vc.fetchSecrets(paths, false)
+
+	results := make(SecretResults, len(paths))
+	for i, r := range fetched {
+		results[i] = SecretResult{
+			Path:     paths[i],
+			Optional: i >= len(secretNames),
+			Data:     r.data,
+			Version:  r.version,
+		}
+		if r.err != nil {
+			results[i].Data = nil
+			results[i].Err = fmt.Errorf("getting secret: %w", r.err)
+		}
+	}
+
+	return results
+}
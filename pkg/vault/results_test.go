@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSecretResults(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/results/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	c := newTestClient(t, cluster)
+
+	t.Run("optional path fails", testOptionalPathFails(c))
+	t.Run("required path fails", testRequiredPathFails(c))
+}
+
+func testOptionalPathFails(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		results, err := c.GetSecretResults(context.Background(), []string{secretEngine}, []string{"kv/data/results/missing"})
+		is.NoErr(err)
+		is.Equal(len(results), 2)
+
+		r, ok := results.Get(secretEngine)
+		is.True(ok)
+		is.True(r.OK())
+		is.True(!r.Optional)
+		is.Equal(r.Data[secretKey], secretValue)
+		is.Equal(r.Version, int64(1))
+
+		r, ok = results.Get("kv/data/results/missing")
+		is.True(ok)
+		is.True(!r.OK())
+		is.True(r.Optional)
+		is.True(errors.Is(r.Err, ErrSecretNotFound))
+
+		is.Equal(results.Values(), map[string]map[string]string{secretEngine: {secretKey: secretValue}})
+	}
+}
+
+func testRequiredPathFails(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		results, err := c.GetSecretResults(context.Background(), []string{"kv/data/results/missing", secretEngine}, nil)
+		is.True(errors.Is(err, ErrSecretNotFound))
+		is.Equal(results[0].Path, "kv/data/results/missing")
+		is.True(!results[0].OK())
+		is.True(results[1].OK())
+		is.Equal(len(results.Values()), 1)
+	}
+}
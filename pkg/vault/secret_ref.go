@@ -0,0 +1,271 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/berglas/pkg/berglas"
+	"github.com/hashicorp/vault/api"
+)
+
+// Schemes of the secret references accepted in any configuration value.
+const (
+	berglasScheme       = "berglas://"
+	fileScheme          = "file://"
+	envScheme           = "env://"
+	secretManagerScheme = "gcp-secretmanager://"
+)
+
+// berglasResolve resolves berglas and secret manager references. It is a
+// variable so tests can run without Google Cloud.
+var berglasResolve = berglas.Resolve
+
+// isSecretRef reports whether s is a secret reference.
+func isSecretRef(s string) bool {
+	for _, scheme := range []string{berglasScheme, fileScheme, envScheme, secretManagerScheme} {
+		if strings.HasPrefix(s, scheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveSecretRef returns the value s refers to when it is a secret
+// reference, and s itself otherwise. References are
+//
+//	berglas://<bucket>/<object>      a secret stored with berglas
+//	gcp-secretmanager://<project>/<secret>[#<version>]
+//	                                  a Google Secret Manager secret
+//	file://<path>                    the contents of a file
+//	env://<name>                     the value of an environment variable
+//
+// Trailing newlines of file contents are removed.
+func resolveSecretRef(ctx context.Context, s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, berglasScheme):
+		plaintext, err := berglasResolve(ctx, s)
+		if err != nil {
+			return "", fmt.Errorf("resolving %s: %w", s, err)
+		}
+
+		return string(plaintext), nil
+	case strings.HasPrefix(s, secretManagerScheme):
+		ref := berglas.ReferencePrefixSecretManager + strings.TrimPrefix(s, secretManagerScheme)
+		plaintext, err := berglasResolve(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("resolving %s: %w", s, err)
+		}
+
+		return string(plaintext), nil
+	case strings.HasPrefix(s, fileScheme):
+		data, err := os.ReadFile(strings.TrimPrefix(s, fileScheme))
+		if err != nil {
+			return "", fmt.Errorf("resolving %s: %w", s, err)
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(s, envScheme):
+		name := strings.TrimPrefix(s, envScheme)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("resolving %s: %w", s, &MissingConfigError{Name: name})
+		}
+
+		return value, nil
+	default:
+		return s, nil
+	}
+}
+
+// resolveSecretRefs replaces every secret reference in the settings of c with
+// the value it refers to, see resolveSecretRef. A reference in a setting that
+// holds the path of a file refers to the contents of the file: the token or
+// ID itself, except for a file reference, which stays a path. It reports
+// every reference that can't be resolved.
+func (c *config) resolveSecretRefs(ctx context.Context) error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"address", &c.address},
+		{"namespace", &c.namespace},
+		{"github token", &c.githubToken},
+		{"gcp project", &c.project},
+		{"gcp service account", &c.serviceAccount},
+		{"gcp auth path", &c.gcpAuthPath},
+		{"vault role", &c.vaultRole},
+		{"kubernetes role", &c.k8sRole},
+		{"kubernetes auth path", &c.k8sAuthPath},
+		{"approle auth path", &c.approle.authPath},
+		{"approle role id", &c.approle.roleID},
+		{"approle secret id", &c.approle.secretID},
+		{"cert auth path", &c.certAuthPath},
+		{"cert role", &c.certRole},
+	}
+
+	var errs []error
+	for _, f := range fields {
+		value, err := resolveSecretRef(ctx, *f.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.name, err))
+			continue
+		}
+
+		*f.value = value
+	}
+
+	files := []struct {
+		name  string
+		path  *string
+		value *string
+	}{
+		{"kubernetes token path", &c.k8sTokenPath, &c.k8sToken},
+		{"approle role id file", &c.approle.roleIDFile, &c.approle.roleID},
+		{"approle secret id file", &c.approle.secretIDFile, &c.approle.secretID},
+	}
+
+	for _, f := range files {
+		// the file is read again at every login, so rotated tokens and
+		// secret IDs are picked up
+		if strings.HasPrefix(*f.path, fileScheme) {
+			*f.path = strings.TrimPrefix(*f.path, fileScheme)
+			continue
+		}
+
+		if !isSecretRef(*f.path) {
+			continue
+		}
+
+		value, err := resolveSecretRef(ctx, *f.path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.name, err))
+			continue
+		}
+
+		// a value set directly wins over a file, like at login
+		*f.path = ""
+		if *f.value == "" {
+			*f.value = value
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolveTLSSecretRefs replaces the secret references in the settings of t,
+// which are resolved apart from the other settings as they are taken from the
+// environment later, see tlsConfig. The settings are paths, so referenced CA
+// certificates are moved to CACertBytes, and a referenced client certificate
+// and key are written to temporary files, which cleanup removes once
+// ConfigureTLS has loaded them.
+func resolveTLSSecretRefs(ctx context.Context, t *api.TLSConfig) (cleanup func(), err error) {
+	var files []string
+	cleanup = func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}
+
+	var errs []error
+	for _, ca := range []*string{&t.CACert, &t.CAPath} {
+		if !isSecretRef(*ca) {
+			continue
+		}
+
+		value, err := resolveSecretRef(ctx, *ca)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tls: %w", err))
+			continue
+		}
+
+		*ca = ""
+		t.CACertBytes = append(t.CACertBytes, value+"\n"...)
+	}
+
+	for _, field := range []*string{&t.ClientCert, &t.ClientKey} {
+		if !isSecretRef(*field) {
+			continue
+		}
+
+		value, err := resolveSecretRef(ctx, *field)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tls: %w", err))
+			continue
+		}
+
+		file, err := writeTempFile(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tls: %w", err))
+			continue
+		}
+
+		files = append(files, file)
+		*field = file
+	}
+
+	serverName, err := resolveSecretRef(ctx, t.TLSServerName)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("tls: %w", err))
+	} else {
+		t.TLSServerName = serverName
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return cleanup, nil
+}
+
+// writeTempFile writes data to a new temporary file only the current user can
+// read, and returns its path.
+func writeTempFile(data string) (string, error) {
+	f, err := os.CreateTemp("", "vault-key-")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	if _, err := f.WriteString(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+// getSecretEnv returns the value of the environment variable name, or
+// defaultVal if it is not set, with a secret reference resolved. Unless
+// keepEnv or VAULT_KEEP_ENVIRONMENT is set, a resolved berglas reference
+// replaces the value of the variable, like berglas.Replace.
+func getSecretEnv(ctx context.Context, name, defaultVal string, keepEnv bool) (string, error) {
+	value := getEnv(name, defaultVal)
+
+	resolved, err := resolveSecretRef(ctx, value)
+	if err != nil {
+		return "", err
+	}
+
+	if !keepEnv {
+		keepEnv, _ = strconv.ParseBool(getEnv("VAULT_KEEP_ENVIRONMENT", "false"))
+	}
+
+	if !keepEnv && strings.HasPrefix(value, berglasScheme) {
+		if err := os.Setenv(name, resolved); err != nil {
+			return "", fmt.Errorf("setting %s: %w", name, err)
+		}
+	}
+
+	return resolved, nil
+}
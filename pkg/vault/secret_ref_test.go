@@ -0,0 +1,238 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+// fakeBerglas replaces berglas for the duration of the test, resolving the
+// references in secrets.
+func fakeBerglas(t *testing.T, secrets map[string]string) {
+	t.Helper()
+
+	resolve := berglasResolve
+	t.Cleanup(func() { berglasResolve = resolve })
+
+	berglasResolve = func(_ context.Context, s string) ([]byte, error) {
+		secret, ok := secrets[s]
+		if !ok {
+			return nil, errors.New("secret not found")
+		}
+
+		return []byte(secret), nil
+	}
+}
+
+func TestResolveSecretRef(t *testing.T) {
+	is := is.New(t)
+
+	fakeBerglas(t, map[string]string{
+		"berglas://bucket/token":   "from-berglas",
+		"sm://project/token#2":     "from-secret-manager",
+		"sm://project/other-token": "latest",
+	})
+
+	file := filepath.Join(t.TempDir(), "token")
+	is.NoErr(os.WriteFile(file, []byte("from-file\n"), 0o600))
+
+	t.Setenv("TOKEN", "from-env")
+
+	for ref, want := range map[string]string{
+		"plain":                               "plain",
+		"":                                    "",
+		"berglas://bucket/token":              "from-berglas",
+		"gcp-secretmanager://project/token#2": "from-secret-manager",
+		"gcp-secretmanager://project/other-token": "latest",
+		"file://" + file: "from-file",
+		"env://TOKEN":    "from-env",
+	} {
+		got, err := resolveSecretRef(context.Background(), ref)
+		is.NoErr(err)
+		is.Equal(got, want)
+	}
+
+	_, err := resolveSecretRef(context.Background(), "env://MISSING_TOKEN")
+	var missing *MissingConfigError
+	is.True(errors.As(err, &missing))
+	is.Equal(missing.Name, "MISSING_TOKEN")
+
+	_, err = resolveSecretRef(context.Background(), "file://"+filepath.Join(t.TempDir(), "missing"))
+	is.True(err != nil)
+
+	_, err = resolveSecretRef(context.Background(), "berglas://bucket/missing")
+	is.True(err != nil)
+}
+
+func TestResolveConfigSecretRefs(t *testing.T) {
+	is := is.New(t)
+
+	fakeBerglas(t, map[string]string{"berglas://bucket/secret-id": "secret"})
+	t.Setenv("ROLE_ID", "role")
+
+	c := &config{
+		githubToken: "token",
+		approle: appRoleConfig{
+			roleID:   "env://ROLE_ID",
+			secretID: "berglas://bucket/secret-id",
+		},
+	}
+	is.NoErr(c.resolveSecretRefs(context.Background()))
+	is.Equal(c.githubToken, "token")
+	is.Equal(c.approle.roleID, "role")
+	is.Equal(c.approle.secretID, "secret")
+
+	// references in file settings resolve to the contents, not a path
+	t.Setenv("K8S_TOKEN", "jwt")
+	c = &config{
+		k8sTokenPath: "env://K8S_TOKEN",
+		approle: appRoleConfig{
+			roleIDFile:   "berglas://bucket/secret-id",
+			secretID:     "direct",
+			secretIDFile: "env://K8S_TOKEN",
+		},
+	}
+	is.NoErr(c.resolveSecretRefs(context.Background()))
+	is.Equal(c.k8sToken, "jwt")
+	is.Equal(c.k8sTokenPath, "")
+	is.Equal(c.approle.roleID, "secret")
+	is.Equal(c.approle.roleIDFile, "")
+	is.Equal(c.approle.secretID, "direct")
+	is.Equal(c.approle.secretIDFile, "")
+
+	c = &config{k8sTokenPath: defaultK8sTokenPath}
+	is.NoErr(c.resolveSecretRefs(context.Background()))
+	is.Equal(c.k8sTokenPath, defaultK8sTokenPath)
+	is.Equal(c.k8sToken, "")
+
+	// file references stay paths, so rotated files are read again at login
+	c = &config{
+		k8sTokenPath: "file:///var/run/secrets/token",
+		approle:      appRoleConfig{secretIDFile: "file:///etc/vault/secret-id"},
+	}
+	is.NoErr(c.resolveSecretRefs(context.Background()))
+	is.Equal(c.k8sTokenPath, "/var/run/secrets/token")
+	is.Equal(c.k8sToken, "")
+	is.Equal(c.approle.secretIDFile, "/etc/vault/secret-id")
+	is.Equal(c.approle.secretID, "")
+
+	// every reference that can't be resolved is reported
+	c = &config{githubToken: "env://MISSING_TOKEN", vaultRole: "berglas://bucket/missing"}
+	err := c.resolveSecretRefs(context.Background())
+	is.True(err != nil)
+	is.Equal(len(err.(interface{ Unwrap() []error }).Unwrap()), 2)
+}
+
+func TestResolveTLSSecretRefs(t *testing.T) {
+	is := is.New(t)
+
+	fakeBerglas(t, map[string]string{
+		"berglas://bucket/ca":   "ca-pem",
+		"berglas://bucket/cert": "cert-pem",
+		"sm://project/key":      "key-pem",
+	})
+	t.Setenv("SERVER_NAME", "vault.example.com")
+
+	tc := &api.TLSConfig{
+		CACert:        "berglas://bucket/ca",
+		ClientCert:    "berglas://bucket/cert",
+		ClientKey:     "gcp-secretmanager://project/key",
+		TLSServerName: "env://SERVER_NAME",
+	}
+	cleanup, err := resolveTLSSecretRefs(context.Background(), tc)
+	is.NoErr(err)
+
+	is.Equal(tc.CACert, "")
+	is.Equal(string(tc.CACertBytes), "ca-pem\n")
+	is.Equal(tc.TLSServerName, "vault.example.com")
+
+	// the client certificate and key are written to private files
+	for file, want := range map[string]string{tc.ClientCert: "cert-pem", tc.ClientKey: "key-pem"} {
+		data, err := os.ReadFile(file)
+		is.NoErr(err)
+		is.Equal(string(data), want)
+
+		info, err := os.Stat(file)
+		is.NoErr(err)
+		is.Equal(info.Mode().Perm(), os.FileMode(0o600))
+	}
+
+	cleanup()
+	for _, file := range []string{tc.ClientCert, tc.ClientKey} {
+		_, err := os.Stat(file)
+		is.True(errors.Is(err, os.ErrNotExist))
+	}
+
+	// paths are left alone
+	tc = &api.TLSConfig{CAPath: "/etc/ssl/certs", ClientCert: "client.crt", ClientKey: "client.key"}
+	_, err = resolveTLSSecretRefs(context.Background(), tc)
+	is.NoErr(err)
+	is.Equal(tc, &api.TLSConfig{CAPath: "/etc/ssl/certs", ClientCert: "client.crt", ClientKey: "client.key"})
+
+	_, err = resolveTLSSecretRefs(context.Background(), &api.TLSConfig{ClientKey: "berglas://bucket/missing"})
+	is.True(err != nil)
+}
+
+func TestSecretRefEnvironment(t *testing.T) {
+	s := newNamespaceServer()
+	defer s.Close()
+
+	fakeBerglas(t, map[string]string{
+		"berglas://bucket/address":   s.URL,
+		"berglas://bucket/namespace": "team",
+	})
+
+	t.Run("replaces berglas references", func(t *testing.T) {
+		is := is.New(t)
+
+		t.Setenv("VAULT_ADDR", "berglas://bucket/address")
+		t.Setenv("VAULT_NAMESPACE", "berglas://bucket/namespace")
+
+		_, err := NewClient(context.Background(), WithAuthClient(&tokenAuthClient{token: "token"}))
+		is.NoErr(err)
+
+		is.Equal(os.Getenv("VAULT_ADDR"), s.URL)
+		is.Equal(os.Getenv("VAULT_NAMESPACE"), "team")
+		is.Equal(s.namespace("auth/token/lookup-self"), "team")
+	})
+
+	t.Run("unchanged environment", func(t *testing.T) {
+		is := is.New(t)
+
+		t.Setenv("VAULT_ADDR", "berglas://bucket/address")
+		t.Setenv("VAULT_NAMESPACE", "berglas://bucket/namespace")
+
+		_, err := NewClient(context.Background(),
+			WithAuthClient(&tokenAuthClient{token: "token"}),
+			WithUnchangedEnvironment(),
+		)
+		is.NoErr(err)
+
+		is.Equal(os.Getenv("VAULT_ADDR"), "berglas://bucket/address")
+		is.Equal(os.Getenv("VAULT_NAMESPACE"), "berglas://bucket/namespace")
+
+		t.Setenv("VAULT_KEEP_ENVIRONMENT", "true")
+		_, err = NewClient(context.Background(), WithAuthClient(&tokenAuthClient{token: "token"}))
+		is.NoErr(err)
+
+		is.Equal(os.Getenv("VAULT_ADDR"), "berglas://bucket/address")
+	})
+
+	t.Run("option values", func(t *testing.T) {
+		is := is.New(t)
+
+		t.Setenv("VAULT_URL", s.URL)
+
+		_, err := NewClient(context.Background(),
+			WithAddress("env://VAULT_URL"),
+			WithNamespace("berglas://bucket/namespace"),
+			WithAuthClient(&tokenAuthClient{token: "token"}),
+		)
+		is.NoErr(err)
+	})
+}
@@ -0,0 +1,133 @@
+package vault
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter of this package.
+const instrumentationName = "github.com/teamsnap/vault-key/pkg/vault"
+
+// Operations recorded by observe.
+const (
+	opLogin = "login"
+	opRead  = "read"
+	opWrite = "write"
+	opList  = "list"
+)
+
+// telemetry holds the OpenTelemetry tracer and instruments of a client.
+type telemetry struct {
+	tracer        trace.Tracer
+	loginDuration metric.Float64Histogram
+	readDuration  metric.Float64Histogram
+	errors        metric.Int64Counter
+}
+
+// globalTelemetry uses the global providers. It serves clients built without
+// NewVaultClient, and forwards to the providers set with otel.SetTracerProvider
+// and otel.SetMeterProvider, even after it is created.
+var globalTelemetry, _ = newTelemetry(nil, nil)
+
+// newTelemetry creates the tracer and instruments from the given providers,
+// or the global ones if nil.
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (*telemetry, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName)
+	t := &telemetry{tracer: tp.Tracer(instrumentationName)}
+
+	var err error
+	t.loginDuration, err = meter.Float64Histogram("vault.client.login.duration",
+		metric.WithDescription("Duration of Vault logins."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	t.readDuration, err = meter.Float64Histogram("vault.client.read.duration",
+		metric.WithDescription("Duration of Vault secret reads."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	t.errors, err = meter.Int64Counter("vault.client.errors",
+		metric.WithDescription("Number of failed Vault operations."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// telemetry returns the telemetry of the client, or the global one.
+func (vc *vaultClient) telemetry() *telemetry {
+	if vc.otel != nil {
+		return vc.otel
+	}
+
+	return globalTelemetry
+}
+
+// observe records the outcome of op, started at start, with attrs on the
+// current span and in the metrics: the duration of logins and reads, and the
+// number of errors. It takes a pointer to the error so it can be deferred.
+// The span is only touched when tracing is enabled, as it is otherwise the
+// span of the caller.
+func (vc *vaultClient) observe(op string, start time.Time, err *error, attrs ...attribute.KeyValue) {
+	t := vc.telemetry()
+	if vc.config.traceEnabled {
+		span := trace.SpanFromContext(vc.ctx)
+		span.SetAttributes(attrs...)
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+
+	// paths are left out of the metrics to keep their cardinality low
+	metricAttrs := []attribute.KeyValue{attribute.String("vault.operation", op)}
+	for _, attr := range attrs {
+		if attr.Key != pathKey {
+			metricAttrs = append(metricAttrs, attr)
+		}
+	}
+
+	if *err != nil {
+		t.errors.Add(vc.ctx, 1, metric.WithAttributes(metricAttrs...))
+	}
+
+	// a failed operation is measured too, so slow failures show up in the
+	// latency as well
+	metricAttrs = append(metricAttrs, attribute.Bool("error", *err != nil))
+	switch op {
+	case opLogin:
+		t.loginDuration.Record(vc.ctx, time.Since(start).Seconds(), metric.WithAttributes(metricAttrs...))
+	case opRead:
+		t.readDuration.Record(vc.ctx, time.Since(start).Seconds(), metric.WithAttributes(metricAttrs...))
+	}
+}
+
+// Attribute keys set by observe.
+const (
+	pathKey       = attribute.Key("vault.path")
+	mountKey      = attribute.Key("vault.mount")
+	authMethodKey = attribute.Key("vault.auth.method")
+)
+
+// pathAttributes returns the attributes of a secret path on mount.
+func pathAttributes(path string, mount kvMount) []attribute.KeyValue {
+	return []attribute.KeyValue{pathKey.String(path), mountKey.String(mount.path)}
+}
@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTelemetry(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/telemetry/foo"
+	is := is.New(t)
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	c := newTestClient(t, cluster,
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+
+	secrets := map[string]map[string]string{}
+	is.NoErr(c.GetSecrets(context.Background(), &secrets, []string{secretEngine}))
+	is.True(c.GetSecrets(context.Background(), &secrets, []string{"kv/data/telemetry/missing"}) != nil)
+
+	// every span started is ended
+	is.Equal(len(spans.Started()), len(spans.Ended()))
+
+	byName := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range spans.Ended() {
+		byName[span.Name()] = append(byName[span.Name()], span)
+	}
+
+	login := byName["vault/login"]
+	is.Equal(len(login), 1)
+	is.True(hasAttribute(login[0].Attributes(), attribute.String("vault.auth.method", "custom")))
+
+	getSecrets, reads := byName["vault/GetSecrets"], byName["vault/SecretFromVault"]
+	is.Equal(len(getSecrets), 2)
+	is.Equal(len(reads), 2)
+
+	for i, read := range reads {
+		// reads are children of their GetSecrets span, not of each other
+		is.Equal(read.Parent().SpanID(), getSecrets[i].SpanContext().SpanID())
+		is.True(hasAttribute(read.Attributes(), attribute.String("vault.mount", "kv/")))
+	}
+
+	is.True(hasAttribute(reads[0].Attributes(), attribute.String("vault.path", secretEngine)))
+	is.Equal(reads[0].Status().Code, codes.Unset)
+	is.Equal(reads[1].Status().Code, codes.Error)
+	is.Equal(len(reads[1].Events()), 1)
+
+	var rm metricdata.ResourceMetrics
+	is.NoErr(reader.Collect(context.Background(), &rm))
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	is.Equal(histogramCount(metrics["vault.client.login.duration"]), uint64(1))
+	is.Equal(histogramCount(metrics["vault.client.read.duration"]), uint64(2))
+
+	errs, ok := metrics["vault.client.errors"].(metricdata.Sum[int64])
+	is.True(ok)
+	is.Equal(len(errs.DataPoints), 1)
+	is.Equal(errs.DataPoints[0].Value, int64(1))
+
+	// paths are not metric attributes
+	_, found := errs.DataPoints[0].Attributes.Value("vault.path")
+	is.True(!found)
+}
+
+func TestTelemetryTracingDisabled(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/telemetry/foo"
+	is := is.New(t)
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	reader := sdkmetric.NewManualReader()
+	c := newTestClient(t, cluster, WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	is.True(!c.vc.config.traceEnabled)
+
+	spans := tracetest.NewSpanRecorder()
+	ctx, caller := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("caller").Start(context.Background(), "caller")
+
+	secrets := map[string]map[string]string{}
+	is.True(c.GetSecrets(ctx, &secrets, []string{"kv/data/telemetry/missing"}) != nil)
+	caller.End()
+
+	// the span of the caller is left alone
+	ended := spans.Ended()
+	is.Equal(len(ended), 1)
+	is.Equal(ended[0].Status().Code, codes.Unset)
+	is.Equal(len(ended[0].Events()), 0)
+	is.Equal(len(ended[0].Attributes()), 0)
+
+	// metrics are still recorded
+	var rm metricdata.ResourceMetrics
+	is.NoErr(reader.Collect(context.Background(), &rm))
+
+	var found bool
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found = found || m.Name == "vault.client.errors"
+		}
+	}
+	is.True(found)
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+func histogramCount(data metricdata.Aggregation) uint64 {
+	h, ok := data.(metricdata.Histogram[float64])
+	if !ok {
+		return 0
+	}
+
+	var count uint64
+	for _, dp := range h.DataPoints {
+		count += dp.Count
+	}
+
+	return count
+}
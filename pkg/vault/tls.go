@@ -0,0 +1,56 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// tlsConfig returns the TLS configuration of the connection to Vault: the one
+// set with the TLS options, with the settings it leaves empty taken from the
+// standard VAULT_CACERT, VAULT_CAPATH, VAULT_CLIENT_CERT, VAULT_CLIENT_KEY,
+// VAULT_TLS_SERVER_NAME and VAULT_SKIP_VERIFY variables unless the config
+// ignores the environment. It returns nil when nothing is configured, so the
+// default TLS settings are used.
+func tlsConfig(c *config) (*api.TLSConfig, error) {
+	if c.ignoreEnv {
+		return c.tlsConfig, nil
+	}
+
+	t := api.TLSConfig{}
+	if c.tlsConfig != nil {
+		t = *c.tlsConfig
+	}
+
+	if t.CACert == "" && len(t.CACertBytes) == 0 && t.CAPath == "" {
+		t.CACert = getEnv("VAULT_CACERT", "")
+		t.CAPath = getEnv("VAULT_CAPATH", "")
+	}
+
+	if t.ClientCert == "" && t.ClientKey == "" {
+		t.ClientCert = getEnv("VAULT_CLIENT_CERT", "")
+		t.ClientKey = getEnv("VAULT_CLIENT_KEY", "")
+	}
+
+	if t.TLSServerName == "" {
+		t.TLSServerName = getEnv("VAULT_TLS_SERVER_NAME", "")
+	}
+
+	if !t.Insecure {
+		if v := getEnv("VAULT_SKIP_VERIFY", ""); v != "" {
+			insecure, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("parsing VAULT_SKIP_VERIFY: %w", err)
+			}
+
+			t.Insecure = insecure
+		}
+	}
+
+	if c.tlsConfig == nil && t.CACert == "" && t.CAPath == "" && t.ClientCert == "" && t.ClientKey == "" && t.TLSServerName == "" && !t.Insecure {
+		return nil, nil
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,155 @@
+package vault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestTLSConfig(t *testing.T) {
+	t.Setenv("VAULT_CACERT", "env-ca.crt")
+	t.Setenv("VAULT_CAPATH", "")
+	t.Setenv("VAULT_CLIENT_CERT", "env.crt")
+	t.Setenv("VAULT_CLIENT_KEY", "env.key")
+	t.Setenv("VAULT_TLS_SERVER_NAME", "env.example.com")
+	t.Setenv("VAULT_SKIP_VERIFY", "true")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected *api.TLSConfig
+	}{
+		{
+			name: "environment",
+			expected: &api.TLSConfig{
+				CACert:        "env-ca.crt",
+				ClientCert:    "env.crt",
+				ClientKey:     "env.key",
+				TLSServerName: "env.example.com",
+				Insecure:      true,
+			},
+		}, {
+			name: "options override environment",
+			opts: []Option{WithCAPath("certs"), WithClientCert("opt.crt", "opt.key"), WithTLSServerName("opt.example.com")},
+			expected: &api.TLSConfig{
+				CAPath:        "certs",
+				ClientCert:    "opt.crt",
+				ClientKey:     "opt.key",
+				TLSServerName: "opt.example.com",
+				Insecure:      true,
+			},
+		}, {
+			name: "ca bytes override environment",
+			opts: []Option{WithTLSConfig(&api.TLSConfig{CACertBytes: []byte("ca")})},
+			expected: &api.TLSConfig{
+				CACertBytes:   []byte("ca"),
+				ClientCert:    "env.crt",
+				ClientKey:     "env.key",
+				TLSServerName: "env.example.com",
+				Insecure:      true,
+			},
+		}, {
+			name: "nil clears options",
+			opts: []Option{WithCAPath("certs"), WithTLSConfig(nil)},
+			expected: &api.TLSConfig{
+				CACert:        "env-ca.crt",
+				ClientCert:    "env.crt",
+				ClientKey:     "env.key",
+				TLSServerName: "env.example.com",
+				Insecure:      true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			c := &config{}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			actual, err := tlsConfig(c)
+			is.NoErr(err)
+			is.Equal(actual, tt.expected)
+		})
+	}
+}
+
+func TestTLSConfigEmpty(t *testing.T) {
+	is := is.New(t)
+
+	for _, name := range []string{"VAULT_CACERT", "VAULT_CAPATH", "VAULT_CLIENT_CERT", "VAULT_CLIENT_KEY", "VAULT_TLS_SERVER_NAME", "VAULT_SKIP_VERIFY"} {
+		t.Setenv(name, "")
+	}
+
+	actual, err := tlsConfig(&config{})
+	is.NoErr(err)
+	is.True(actual == nil)
+
+	t.Setenv("VAULT_SKIP_VERIFY", "maybe")
+	_, err = tlsConfig(&config{})
+	is.True(err != nil)
+
+	// clients built from a Config don't read the environment
+	actual, err = tlsConfig(&config{ignoreEnv: true})
+	is.NoErr(err)
+	is.True(actual == nil)
+
+	t.Setenv("VAULT_CACERT", "env-ca.crt")
+	actual, err = tlsConfig(&config{ignoreEnv: true, tlsConfig: &api.TLSConfig{TLSServerName: "vault.example.com"}})
+	is.NoErr(err)
+	is.Equal(actual, &api.TLSConfig{TLSServerName: "vault.example.com"})
+}
+
+func TestTLSConnection(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/tls/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, cluster.CACertPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	address := cluster.Cores[0].Client.Address()
+	auth := &tokenAuthClient{token: cluster.RootToken}
+
+	t.Run("ca from environment", func(t *testing.T) {
+		is := is.New(t)
+		t.Setenv("VAULT_CACERT", caFile)
+
+		_, err := NewClient(context.Background(), WithAddress(address), WithAuthClient(auth))
+		is.NoErr(err)
+	})
+
+	t.Run("wrong server name", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := NewClient(context.Background(),
+			WithAddress(address),
+			WithAuthClient(auth),
+			WithCACert(caFile),
+			WithTLSServerName("vault.example.com"),
+		)
+		is.True(err != nil)
+	})
+
+	t.Run("skip verify", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := NewClient(context.Background(),
+			WithAddress(address),
+			WithAuthClient(auth),
+			WithTLSServerName("vault.example.com"),
+			WithTLSSkipVerify(),
+		)
+		is.NoErr(err)
+	})
+}
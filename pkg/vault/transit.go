@@ -0,0 +1,262 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Transit encrypts, decrypts, signs and hashes data with the keys of a
+// Transit secrets engine, without the keys ever leaving Vault. Get one with
+// Client.Transit.
+type Transit struct {
+	c     *Client
+	mount string
+}
+
+// Transit returns the Transit secrets engine mounted at mount, "transit" if
+// empty.
+func (c *Client) Transit(mount string) *Transit {
+	if mount == "" {
+		mount = "transit"
+	}
+
+	return &Transit{c: c, mount: mount}
+}
+
+// Encrypt encrypts plaintext with key and returns the ciphertext, in the
+// "vault:v1:..." form Vault returns it.
+func (t *Transit) Encrypt(ctx context.Context, key string, plaintext []byte) (string, error) {
+	results, err := t.EncryptBatch(ctx, key, [][]byte{plaintext})
+	if err != nil {
+		return "", err
+	}
+
+	return results[0], nil
+}
+
+// EncryptBatch encrypts every plaintext with key in a single request.
+func (t *Transit) EncryptBatch(ctx context.Context, key string, plaintexts [][]byte) ([]string, error) {
+	vc := t.c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/Encrypt", vc.config.tracePrefix))()
+
+	input := make([]map[string]interface{}, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		input[i] = map[string]interface{}{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+	}
+
+	return vc.transitStrings(t.mount, "encrypt", key, input, "ciphertext")
+}
+
+// Decrypt decrypts ciphertext with key and returns the plaintext.
+func (t *Transit) Decrypt(ctx context.Context, key, ciphertext string) ([]byte, error) {
+	results, err := t.DecryptBatch(ctx, key, []string{ciphertext})
+	if err != nil {
+		return nil, err
+	}
+
+	return results[0], nil
+}
+
+// DecryptBatch decrypts every ciphertext with key in a single request.
+func (t *Transit) DecryptBatch(ctx context.Context, key string, ciphertexts []string) ([][]byte, error) {
+	vc := t.c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/Decrypt", vc.config.tracePrefix))()
+
+	input := make([]map[string]interface{}, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		input[i] = map[string]interface{}{"ciphertext": ciphertext}
+	}
+
+	encoded, err := vc.transitStrings(t.mount, "decrypt", key, input, "plaintext")
+	if err != nil {
+		return nil, err
+	}
+
+	plaintexts := make([][]byte, len(encoded))
+	for i, s := range encoded {
+		if plaintexts[i], err = base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("decoding plaintext %d from %s/decrypt/%s: %w", i, t.mount, key, err)
+		}
+	}
+
+	return plaintexts, nil
+}
+
+// Rewrap encrypts ciphertext again with the latest version of key, without
+// returning the plaintext.
+func (t *Transit) Rewrap(ctx context.Context, key, ciphertext string) (string, error) {
+	results, err := t.RewrapBatch(ctx, key, []string{ciphertext})
+	if err != nil {
+		return "", err
+	}
+
+	return results[0], nil
+}
+
+// RewrapBatch rewraps every ciphertext with key in a single request.
+func (t *Transit) RewrapBatch(ctx context.Context, key string, ciphertexts []string) ([]string, error) {
+	vc := t.c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/Rewrap", vc.config.tracePrefix))()
+
+	input := make([]map[string]interface{}, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		input[i] = map[string]interface{}{"ciphertext": ciphertext}
+	}
+
+	return vc.transitStrings(t.mount, "rewrap", key, input, "ciphertext")
+}
+
+// Sign signs input with key, which must be of a type that supports signing,
+// and returns the signature.
+func (t *Transit) Sign(ctx context.Context, key string, input []byte) (string, error) {
+	results, err := t.SignBatch(ctx, key, [][]byte{input})
+	if err != nil {
+		return "", err
+	}
+
+	return results[0], nil
+}
+
+// SignBatch signs every input with key in a single request.
+func (t *Transit) SignBatch(ctx context.Context, key string, inputs [][]byte) ([]string, error) {
+	vc := t.c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/Sign", vc.config.tracePrefix))()
+
+	return vc.transitStrings(t.mount, "sign", key, encodeInputs(inputs), "signature")
+}
+
+// Verify reports whether signature is a valid signature of input made with
+// key.
+func (t *Transit) Verify(ctx context.Context, key string, input []byte, signature string) (bool, error) {
+	results, err := t.VerifyBatch(ctx, key, [][]byte{input}, []string{signature})
+	if err != nil {
+		return false, err
+	}
+
+	return results[0], nil
+}
+
+// VerifyBatch verifies the signature of every input with key in a single
+// request. inputs and signatures must have the same length.
+func (t *Transit) VerifyBatch(ctx context.Context, key string, inputs [][]byte, signatures []string) ([]bool, error) {
+	vc := t.c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/Verify", vc.config.tracePrefix))()
+
+	if len(inputs) != len(signatures) {
+		return nil, fmt.Errorf("got %d inputs but %d signatures to verify", len(inputs), len(signatures))
+	}
+
+	input := encodeInputs(inputs)
+	for i, signature := range signatures {
+		input[i]["signature"] = signature
+	}
+
+	results, err := vc.transitBatch(t.mount, "verify", key, input)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make([]bool, len(results))
+	for i, result := range results {
+		valid[i], _ = result["valid"].(bool)
+	}
+
+	return valid, nil
+}
+
+// HMAC returns the HMAC of input made with key.
+func (t *Transit) HMAC(ctx context.Context, key string, input []byte) (string, error) {
+	results, err := t.HMACBatch(ctx, key, [][]byte{input})
+	if err != nil {
+		return "", err
+	}
+
+	return results[0], nil
+}
+
+// HMACBatch returns the HMAC of every input made with key in a single request.
+func (t *Transit) HMACBatch(ctx context.Context, key string, inputs [][]byte) ([]string, error) {
+	vc := t.c.vc.withContext(ctx)
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/HMAC", vc.config.tracePrefix))()
+
+	return vc.transitStrings(t.mount, "hmac", key, encodeInputs(inputs), "hmac")
+}
+
+func encodeInputs(inputs [][]byte) []map[string]interface{} {
+	input := make([]map[string]interface{}, len(inputs))
+	for i, in := range inputs {
+		input[i] = map[string]interface{}{"input": base64.StdEncoding.EncodeToString(in)}
+	}
+
+	return input
+}
+
+// transitStrings runs a batch Transit operation and returns the string field
+// of every result.
+func (vc *vaultClient) transitStrings(mount, op, key string, input []map[string]interface{}, field string) ([]string, error) {
+	results, err := vc.transitBatch(mount, op, key, input)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(results))
+	for i, result := range results {
+		values[i], _ = result[field].(string)
+		if values[i] == "" {
+			return nil, fmt.Errorf("no %s returned for item %d from %s/%s/%s", field, i, mount, op, key)
+		}
+	}
+
+	return values, nil
+}
+
+// transitBatch writes input as the batch_input of a Transit operation and
+// returns the batch results, failing if any item failed.
+func (vc *vaultClient) transitBatch(mount, op, key string, input []map[string]interface{}) ([]map[string]interface{}, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/transit/write", vc.config.tracePrefix))()
+
+	path := fmt.Sprintf("%s/%s/%s", mount, op, key)
+	secret, err := vc.client.Logical().WriteWithContext(vc.ctx, path, map[string]interface{}{
+		"batch_input": input,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transit %s with key %s: %w", op, key, err)
+	}
+
+	items, err := batchResults(secret)
+	if err != nil {
+		return nil, fmt.Errorf("transit %s with key %s: %w", op, key, err)
+	}
+
+	if len(items) != len(input) {
+		return nil, fmt.Errorf("transit %s with key %s: got %d results for %d items", op, key, len(items), len(input))
+	}
+
+	return items, nil
+}
+
+func batchResults(secret *api.Secret) ([]map[string]interface{}, error) {
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no batch results returned")
+	}
+
+	raw, _ := secret.Data["batch_results"].([]interface{})
+	items := make([]map[string]interface{}, len(raw))
+	for i, r := range raw {
+		item, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected batch result %d: %v", i, r)
+		}
+
+		if msg, _ := item["error"].(string); msg != "" {
+			return nil, fmt.Errorf("item %d: %s", i, msg)
+		}
+
+		items[i] = item
+	}
+
+	return items, nil
+}
@@ -0,0 +1,126 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/matryer/is"
+)
+
+func TestTransit(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/transit/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	if err := rootVaultClient.Sys().Mount("transit", &api.MountInput{Type: "transit"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, keyType := range map[string]string{"app": "aes256-gcm96", "signer": "ed25519"} {
+		if _, err := rootVaultClient.Logical().Write("transit/keys/"+key, map[string]interface{}{"type": keyType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := newTestClient(t, cluster)
+
+	t.Run("encrypt and decrypt", testTransitEncrypt(c.Transit("")))
+	t.Run("batch", testTransitBatch(c.Transit("transit")))
+	t.Run("rewrap", testTransitRewrap(c.Transit(""), rootVaultClient))
+	t.Run("sign and verify", testTransitSign(c.Transit("")))
+	t.Run("hmac", testTransitHMAC(c.Transit("")))
+	t.Run("missing key", testTransitMissingKey(c.Transit("")))
+}
+
+func testTransitEncrypt(tr *Transit) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		ciphertext, err := tr.Encrypt(context.Background(), "app", []byte("hunter2"))
+		is.NoErr(err)
+		is.True(strings.HasPrefix(ciphertext, "vault:v1:"))
+
+		plaintext, err := tr.Decrypt(context.Background(), "app", ciphertext)
+		is.NoErr(err)
+		is.Equal(string(plaintext), "hunter2")
+	}
+}
+
+func testTransitBatch(tr *Transit) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		ciphertexts, err := tr.EncryptBatch(context.Background(), "app", [][]byte{[]byte("one"), []byte("two")})
+		is.NoErr(err)
+		is.Equal(len(ciphertexts), 2)
+
+		plaintexts, err := tr.DecryptBatch(context.Background(), "app", ciphertexts)
+		is.NoErr(err)
+		is.Equal(string(plaintexts[0]), "one")
+		is.Equal(string(plaintexts[1]), "two")
+	}
+}
+
+func testTransitRewrap(tr *Transit, root *api.Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		ciphertext, err := tr.Encrypt(context.Background(), "app", []byte("hunter2"))
+		is.NoErr(err)
+
+		_, err = root.Logical().Write("transit/keys/app/rotate", nil)
+		is.NoErr(err)
+
+		rewrapped, err := tr.Rewrap(context.Background(), "app", ciphertext)
+		is.NoErr(err)
+		is.True(strings.HasPrefix(rewrapped, "vault:v2:"))
+
+		plaintext, err := tr.Decrypt(context.Background(), "app", rewrapped)
+		is.NoErr(err)
+		is.Equal(string(plaintext), "hunter2")
+	}
+}
+
+func testTransitSign(tr *Transit) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		signature, err := tr.Sign(context.Background(), "signer", []byte("release-1.2.3"))
+		is.NoErr(err)
+
+		valid, err := tr.Verify(context.Background(), "signer", []byte("release-1.2.3"), signature)
+		is.NoErr(err)
+		is.True(valid)
+
+		results, err := tr.VerifyBatch(context.Background(), "signer",
+			[][]byte{[]byte("release-1.2.3"), []byte("release-6.6.6")},
+			[]string{signature, signature},
+		)
+		is.NoErr(err)
+		is.Equal(results, []bool{true, false})
+	}
+}
+
+func testTransitHMAC(tr *Transit) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		hmacs, err := tr.HMACBatch(context.Background(), "app", [][]byte{[]byte("a"), []byte("a"), []byte("b")})
+		is.NoErr(err)
+		is.Equal(hmacs[0], hmacs[1])
+		is.True(hmacs[0] != hmacs[2])
+	}
+}
+
+func testTransitMissingKey(tr *Transit) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := tr.Decrypt(context.Background(), "missing", "vault:v1:AAAA")
+		is.True(err != nil)
+	}
+}
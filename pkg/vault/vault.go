@@ -3,205 +3,226 @@ package vault
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
-
-	"github.com/GoogleCloudPlatform/berglas/pkg/berglas"
-	log "github.com/sirupsen/logrus"
+	"time"
 )
 
 // NewVaultToken uses a github token or service account to get a vault auth token
 func NewVaultToken(vc *vaultClient) (string, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/NewVaultToken", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/NewVaultToken", vc.config.tracePrefix))()
 
-	return NewAuthClient(vc.config).GetVaultToken(vc)
+	a, err := NewAuthClient(vc.config)
+	if err != nil {
+		return "", err
+	}
+
+	return a.GetVaultToken(vc)
 }
 
 // ListEngines fills a map with the secrets engines pulled from Vault.
 func ListEngines(ctx context.Context, path string) ([]string, error) {
-	config, err := getConfig()
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	vc, err := NewVaultClient(ctx, config)
+	return c.ListEngines(ctx, path)
+}
 
+// GetSecrets fills a map with the values of secrets pulled from Vault.
+func GetSecrets(ctx context.Context, secretValues *map[string]map[string]string, secretNames []string) error {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error initializing vault client: %v", err)
+		return err
 	}
 
-	vc.tracer.trace(fmt.Sprintf("%s/ListEngines", vc.config.tracePrefix))
+	return c.GetSecrets(ctx, secretValues, secretNames)
+}
 
-	engine, err := vc.enginesFromVault(path)
+// GetSecretResults reads secrets from Vault and reports the outcome for each path. Paths in
+// optionalNames may fail without failing the call.
+func GetSecretResults(ctx context.Context, secretNames, optionalNames []string) (SecretResults, error) {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getting engines: %v", err)
+		return nil, err
 	}
 
-	return engine, nil
+	return c.GetSecretResults(ctx, secretNames, optionalNames)
 }
 
-// GetSecrets fills a map with the values of secrets pulled from Vault.
-func GetSecrets(ctx context.Context, secretValues *map[string]map[string]string, secretNames []string) error {
-	config, err := getConfig()
+// GetSecretData returns the data of a secret pulled from Vault with its original types.
+func GetSecretData(ctx context.Context, secretName string) (map[string]interface{}, error) {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return err
-	}
-
-	vc, err := NewVaultClient(ctx, config)
-	if err != nil {
-		return fmt.Errorf("error initializing vault client: %w", err)
+		return nil, err
 	}
 
-	vc.tracer.trace(fmt.Sprintf("%s/GetSecrets", vc.config.tracePrefix))
-
-	for _, secretName := range secretNames {
-		secret, err := vc.SecretFromVault(secretName)
-		if err != nil {
-			return fmt.Errorf("getting secret: %w", err)
-		}
+	return c.GetSecretData(ctx, secretName)
+}
 
-		(*secretValues)[secretName] = secret
+// DecodeSecret decodes the data of a secret pulled from Vault into v.
+func DecodeSecret(ctx context.Context, secretName string, v interface{}) error {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return c.DecodeSecret(ctx, secretName, v)
 }
 
 // CreateSecret takes a given key for an engine, and adds a new key/value pair in vault.
 func CreateSecret(ctx context.Context, engine, key, value string) error {
-	config, err := getConfig()
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	vc, err := NewVaultClient(ctx, config)
-	if err != nil {
-		return fmt.Errorf("error initializing vault client: %w", err)
-	}
-
-	vc.tracer.trace(fmt.Sprintf("%s/CreateSecret", vc.config.tracePrefix))
+	return c.CreateSecret(ctx, engine, key, value)
+}
 
-	if _, err := vc.create(engine, key, value); err != nil {
+// UpdateSecret takes a given key for an engine, and modifies its value in vault.
+func UpdateSecret(ctx context.Context, engine, key, value string) error {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.UpdateSecret(ctx, engine, key, value)
 }
 
-// UpdateSecret takes a given key for an engine, and modifies its value in vault.
-func UpdateSecret(ctx context.Context, engine, key, value string) error {
-	config, err := getConfig()
+func DeleteSecret(ctx context.Context, engine, key string) error {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	vc, err := NewVaultClient(ctx, config)
+	return c.DeleteSecret(ctx, engine, key)
+}
+
+// CreatePath takes a given path, and adds it to an existing KV v2 engine
+func CreatePath(ctx context.Context, path string) error {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return fmt.Errorf("error initializing vault client: %w", err)
+		return err
 	}
 
-	vc.tracer.trace(fmt.Sprintf("%s/UpdateSecret", vc.config.tracePrefix))
+	return c.CreatePath(ctx, path)
+}
 
-	if _, err := vc.update(engine, key, value); err != nil {
+// GetSecretVersions fills a map with the versions of secrets pulled from Vault.
+func GetSecretVersions(ctx context.Context, secretVersions *map[string]int64, secretNames []string) error {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.GetSecretVersions(ctx, secretVersions, secretNames)
 }
 
-func DeleteSecret(ctx context.Context, engine, key string) error {
-	config, err := getConfig()
+// GetSecretVersion returns the data of the given version of a KV v2 secret pulled from Vault.
+func GetSecretVersion(ctx context.Context, secretName string, version int64) (map[string]interface{}, error) {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	vc, err := NewVaultClient(ctx, config)
+	return c.GetSecretVersion(ctx, secretName, version)
+}
+
+// GetSecretMetadata returns the metadata of a KV v2 secret pulled from Vault.
+func GetSecretMetadata(ctx context.Context, secretName string) (*SecretMetadata, error) {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return fmt.Errorf("error initializing vault client: %w", err)
+		return nil, err
 	}
 
-	vc.tracer.trace(fmt.Sprintf("%s/DeleteSecret", vc.config.tracePrefix))
+	return c.GetSecretMetadata(ctx, secretName)
+}
 
-	if _, err := vc.delete(engine, key); err != nil {
+// RollbackSecret writes the data of an earlier version of a KV v2 secret as its new latest version.
+func RollbackSecret(ctx context.Context, secretName string, version int64) error {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.RollbackSecret(ctx, secretName, version)
 }
 
-// CreatePath takes a given path, and adds it to an existing KV v2 engine
-func CreatePath(ctx context.Context, path string) error {
-	config, err := getConfig()
+// DeleteSecretVersions soft deletes versions of a KV v2 secret, or its latest version when no versions are given.
+func DeleteSecretVersions(ctx context.Context, secretName string, versions ...int64) error {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	vc, err := NewVaultClient(ctx, config)
+	return c.DeleteSecretVersions(ctx, secretName, versions...)
+}
+
+// UndeleteSecretVersions restores soft deleted versions of a KV v2 secret.
+func UndeleteSecretVersions(ctx context.Context, secretName string, versions ...int64) error {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return fmt.Errorf("error initializing vault client: %w", err)
+		return err
 	}
 
-	vc.tracer.trace(fmt.Sprintf("%s/CreatePath", vc.config.tracePrefix))
+	return c.UndeleteSecretVersions(ctx, secretName, versions...)
+}
 
-	if err := vc.createPath(path); err != nil {
+// DestroySecretVersions permanently removes the data of versions of a KV v2 secret.
+func DestroySecretVersions(ctx context.Context, secretName string, versions ...int64) error {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.DestroySecretVersions(ctx, secretName, versions...)
 }
 
-// GetSecretVersions fills a map with the versions of secrets pulled from Vault.
-func GetSecretVersions(ctx context.Context, secretVersions *map[string]int64, secretNames []string) error {
-	config, err := getConfig()
+// DeleteSecretMetadata permanently removes a KV v2 secret with all of its versions and metadata.
+func DeleteSecretMetadata(ctx context.Context, secretName string) error {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	vc, err := NewVaultClient(ctx, config)
+	return c.DeleteSecretMetadata(ctx, secretName)
+}
+
+// Watch sends a SecretChange whenever the version of one of the KV v2 secrets at paths changes,
+// checking every interval until ctx is done.
+func Watch(ctx context.Context, paths []string, interval time.Duration) (<-chan SecretChange, error) {
+	c, err := newEnvironmentClient(ctx)
 	if err != nil {
-		return fmt.Errorf("error initializing vault client: %w", err)
+		return nil, err
 	}
 
-	vc.tracer.trace(fmt.Sprintf("%s/GetSecretVersions", vc.config.tracePrefix))
-
-	for _, secretName := range secretNames {
-		secretVersion, err := vc.SecretVersionFromVault(secretName)
-		if err != nil {
-			return fmt.Errorf("getting secret version: %w", err)
-		}
+	return c.Watch(ctx, paths, interval)
+}
 
-		(*secretVersions)[secretName] = secretVersion
+// NewDatabaseLease issues credentials for role from the database secrets engine mounted at mount
+// and keeps their lease alive until Close is called on the lease.
+func NewDatabaseLease(ctx context.Context, mount, role string, handlers ...func(CredentialsEvent)) (*DatabaseLease, error) {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return c.NewDatabaseLease(ctx, mount, role, handlers...)
 }
 
-// getEncrEnvVar takes the name of an environment variable that's value begins
-// with "berglas://", decrypts the value from a Google Storage Bucket with KMS,
-// replaces the original environment variable value with the decrypted value,
-// and returns the value as a string. If there's an error fetching the value, it
-// will return an empty string along with the error message.
-func getEncrEnvVar(ctx context.Context, n string) (string, error) {
-	val := os.Getenv(n)
-	if strings.HasPrefix(val, "berglas://") {
-		if err := berglas.Replace(ctx, n); err != nil {
-			return "", err
-		}
+// IssueCertificate issues a certificate from role on the PKI secrets engine mounted at mount.
+func IssueCertificate(ctx context.Context, mount, role string, req CertificateRequest) (*Certificate, error) {
+	c, err := newEnvironmentClient(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return os.Getenv(n), nil
+	return c.IssueCertificate(ctx, mount, role, req)
 }
 
+// getConfig loads the client configuration from the environment, without
+// touching any global state.
 func getConfig() (*config, error) {
-	environment := os.Getenv("ENVIRONMENT")
-
-	if environment == "production" {
-		log.SetFormatter(&log.JSONFormatter{})
-		log.SetLevel(log.WarnLevel)
-	} else {
-		log.SetFormatter(&log.TextFormatter{})
-		log.SetLevel(log.TraceLevel)
-	}
-
 	config, err := loadVaultEnvironment()
 	if err != nil {
 		return nil, fmt.Errorf("load client environment: %w", err)
@@ -2,18 +2,33 @@ package vault
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/api"
-	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type vaultClient struct {
 	client *api.Client
 	config *config
 	ctx    context.Context
+	// auth is the login response the client token came from.
+	auth *api.Secret
+	// mounts caches the KV version of each mount, shared by all copies.
+	mounts *mountCache
+	// cache caches secret data when WithCache is set, shared by all copies.
+	cache *secretCache
+	// otel holds the OpenTelemetry tracer and instruments.
+	otel *telemetry
+	// log is the configured logger, with sensitive attributes redacted.
+	log *slog.Logger
 	tracer
 }
 
@@ -22,10 +37,26 @@ func NewVaultClient(ctx context.Context, c *config) (*vaultClient, error) {
 	client := &vaultClient{
 		config: c,
 		ctx:    ctx,
+		mounts: newMountCache(),
+		log:    newLogger(c.logger),
 	}
 	client.tracer = client
 
-	err := initClient(client)
+	if c.cacheTTL > 0 {
+		client.cache = newSecretCache(c.cacheTTL, c.cacheRevalidate)
+	}
+
+	err := c.resolveSecretRefs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolving secret references: %w", err)
+	}
+
+	client.otel, err = newTelemetry(c.tracerProvider, c.meterProvider)
+	if err != nil {
+		return nil, fmt.Errorf("initializing telemetry: %w", err)
+	}
+
+	err = initClient(client)
 	if err != nil {
 		return nil, fmt.Errorf("initialze client: %w", err)
 	}
@@ -33,71 +64,265 @@ func NewVaultClient(ctx context.Context, c *config) (*vaultClient, error) {
 	return client, nil
 }
 
+// withContext returns a copy of the client bound to ctx, so that a shared
+// client can serve concurrent calls without racing on vc.ctx. If ctx carries a
+// namespace set with ContextWithNamespace, the copy uses it.
+func (vc *vaultClient) withContext(ctx context.Context) *vaultClient {
+	c := *vc
+	c.ctx = ctx
+	if vc.tracer == tracer(vc) {
+		c.tracer = &c
+	}
+
+	if namespace, ok := namespaceFromContext(ctx); ok && vc.client != nil && namespace != vc.client.Namespace() {
+		c.client = vc.client.WithNamespace(namespace)
+	}
+
+	return &c
+}
+
 // initClient takes context and a vault role and returns an initialized Vault
-// client using the value in the "VAULT_ADDR" env var.
-// It will exit the process if it fails to initialize.
+// client using the configured address, or the value in the "VAULT_ADDR" env var.
+// The namespace is likewise taken from the config or "VAULT_NAMESPACE", and
+// applies to the login as well as every later request. TLS settings left empty
+// by the config are taken from the standard VAULT_* variables, see tlsConfig.
+// Secret references in the variables are resolved, see resolveSecretRef. None
+// of the variables are read when the config ignores the environment.
 func initClient(vc *vaultClient) error {
-	vc.tracer.trace(fmt.Sprintf("%s/initClient", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/initClient", vc.config.tracePrefix))()
 
-	vaultAddr, err := getEncrEnvVar(vc.ctx, "VAULT_ADDR")
-	if err != nil {
-		return fmt.Errorf("vault address: %w", err)
+	vaultAddr := vc.config.address
+	if vaultAddr == "" && !vc.config.ignoreEnv {
+		var err error
+		vaultAddr, err = getSecretEnv(vc.ctx, "VAULT_ADDR", "", vc.config.keepEnv)
+		if err != nil {
+			return fmt.Errorf("vault address: %w", err)
+		}
+	}
+
+	if vaultAddr == "" {
+		return &MissingConfigError{Name: "VAULT_ADDR"}
 	}
 
-	vc.client, err = api.NewClient(&api.Config{
+	apiConfig := &api.Config{
 		Address: vaultAddr,
-	})
+	}
+	if vc.config.ignoreEnv {
+		// the default HTTP client of the api package is configured from the
+		// VAULT_* variables
+		apiConfig.HttpClient = newHTTPClient()
+	}
+	vc.config.request.configure(apiConfig)
+
+	t, err := tlsConfig(vc.config)
+	if err != nil {
+		return fmt.Errorf("configuring tls: %w", err)
+	}
+
+	if t != nil {
+		cleanup, err := resolveTLSSecretRefs(vc.ctx, t)
+		if err != nil {
+			return fmt.Errorf("configuring tls: %w", err)
+		}
+
+		err = apiConfig.ConfigureTLS(t)
+		cleanup()
+		if err != nil {
+			return fmt.Errorf("configuring tls: %w", err)
+		}
+	}
+
+	vc.client, err = api.NewClient(apiConfig)
 	if err != nil {
 		return fmt.Errorf("initializing new vault api client: %w", err)
 	}
 
-	token, err := NewVaultToken(vc)
+	namespace := vc.config.namespace
+	if namespace == "" && !vc.config.ignoreEnv {
+		namespace, err = getSecretEnv(vc.ctx, "VAULT_NAMESPACE", "", vc.config.keepEnv)
+		if err != nil {
+			return fmt.Errorf("vault namespace: %w", err)
+		}
+	}
+
+	switch {
+	case namespace != "":
+		vc.client.SetNamespace(namespace)
+	case vc.config.ignoreEnv:
+		// api.NewClient takes the namespace from VAULT_NAMESPACE
+		vc.client.ClearNamespace()
+	}
+
+	vc.auth, err = vc.login()
 	if err != nil {
 		return fmt.Errorf("getting vault api token from client: %w", err)
 	}
 
-	vc.client.SetToken(token)
-	return err
+	return nil
 }
 
 // SecretFromVault takes a secret name and returns the value returned from vault as a string.
+// Values that aren't strings are converted: numbers and booleans to their
+// literal form, lists and objects to JSON.
 func (vc *vaultClient) SecretFromVault(secretName string) (map[string]string, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/SecretFromVault", vc.config.tracePrefix))
+	secretMap, _, err := vc.secretStrings(secretName)
 
+	return secretMap, err
+}
+
+// secretStrings reads a secret like SecretFromVault does, and also returns
+// its version.
+func (vc *vaultClient) secretStrings(secretName string) (map[string]string, int64, error) {
+	m, version, err := vc.readCachedSecret(secretName)
+	if err != nil {
+		return map[string]string{}, 0, err
+	}
+
+	secretMap, err := stringValues(secretName, m)
+	if err != nil {
+		return secretMap, 0, err
+	}
+
+	return secretMap, version, nil
+}
+
+// stringValues converts the values of secret data to strings the way
+// SecretFromVault does.
+func stringValues(secretName string, m map[string]interface{}) (map[string]string, error) {
 	secretMap := map[string]string{}
+	for key, value := range m {
+		s, err := stringValue(value)
+		if err != nil {
+			return map[string]string{}, fmt.Errorf("converting value of key %s for %s to a string: %w", key, secretName, err)
+		}
 
-	secretValues, err := vc.client.Logical().Read(secretName)
+		secretMap[key] = s
+	}
+
+	return secretMap, nil
+}
+
+// SecretDataFromVault takes a secret name and returns the data returned from
+// vault with its original types.
+func (vc *vaultClient) SecretDataFromVault(secretName string) (map[string]interface{}, error) {
+	return vc.readSecretData(secretName)
+}
+
+// readSecretData reads a KV v1 or v2 secret and returns its data.
+func (vc *vaultClient) readSecretData(secretName string) (map[string]interface{}, error) {
+	data, _, err := vc.readCachedSecret(secretName)
+
+	return data, err
+}
+
+// readSecret reads a KV v1 or v2 secret and returns its data and version. The
+// version is always 0 on KV v1, which does not keep versions.
+func (vc *vaultClient) readSecret(secretName string) (map[string]interface{}, int64, error) {
+	return vc.readSecretVersion(secretName, 0)
+}
+
+// readSecretVersion reads the given version of a KV v2 secret and returns its
+// data and version. Version 0 reads the latest version, and is the only
+// version that can be read from KV v1.
+func (vc *vaultClient) readSecretVersion(secretName string, version int64) (_ map[string]interface{}, _ int64, err error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/SecretFromVault", vc.config.tracePrefix))()
+
+	mount := vc.kvMount(secretName)
+	defer vc.observe(opRead, time.Now(), &err, pathAttributes(secretName, mount)...)
+	if version != 0 && !mount.isV2() {
+		return nil, 0, fmt.Errorf("secret %s is on KV v1 mount %s, which has no versions", secretName, mount.path)
+	}
+
+	var query map[string][]string
+	if version != 0 {
+		query = map[string][]string{"version": {strconv.FormatInt(version, 10)}}
+	}
+
+	secretValues, err := vc.client.Logical().ReadWithDataWithContext(vc.ctx, mount.apiPath(secretName, "data"), query)
 	if err != nil {
-		return secretMap, fmt.Errorf("reading secret from Vault for %s", secretName)
+		return nil, 0, fmt.Errorf("reading secret from Vault for %s: %w", secretName, err)
 	}
 
 	if secretValues == nil {
-		return secretMap, fmt.Errorf("secret values returned from Vault are <nil> for %s", secretName)
+		return nil, 0, fmt.Errorf("secret values returned from Vault are <nil> for %s: %w", secretName, ErrSecretNotFound)
+	}
+
+	if !mount.isV2() {
+		return secretValues.Data, 0, nil
+	}
+
+	if secretValues.Data["data"] == nil {
+		return nil, 0, fmt.Errorf("secret %s has been deleted or destroyed: %w", secretName, ErrSecretNotFound)
 	}
 
-	// https://stackoverflow.com/questions/26975880/convert-mapinterface-interface-to-mapstringstring
 	m, ok := secretValues.Data["data"].(map[string]interface{})
-	if ok {
-		for key, value := range m {
-			secretMap[key] = value.(string)
+	if !ok {
+		return nil, 0, fmt.Errorf("converting secret data from Vault to a string for %s", secretName)
+	}
+
+	if metadata, ok := secretValues.Data["metadata"].(map[string]interface{}); ok {
+		version = jsonInt(metadata["version"])
+	}
+
+	return m, version, nil
+}
+
+// stringValue converts a value decoded from a Vault response to a string.
+func stringValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
 		}
 
-		return secretMap, nil
+		return string(b), nil
 	}
+}
 
-	return secretMap, fmt.Errorf("converting secret data from Vault to a string for %s", secretName)
+// decodeSecret decodes secret data into v, which should be a pointer to a
+// struct or map, following the rules of encoding/json.
+func decodeSecret(data map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encoding secret data: %w", err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decoding secret data: %w", err)
+	}
+
+	return nil
 }
 
 // SecretVersionFromVault takes a secret name and returns the version of the Vault secret as an int.
 func (vc *vaultClient) SecretVersionFromVault(secretName string) (int64, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/SecretVersionFromVault", vc.config.tracePrefix))
+	defer vc.tracer.trace(fmt.Sprintf("%s/SecretVersionFromVault", vc.config.tracePrefix))()
 
 	var version int64
-	secretValues, err := vc.client.Logical().Read(secretName)
+
+	mount := vc.kvMount(secretName)
+	if !mount.isV2() {
+		return version, fmt.Errorf("secret %s is on KV v1 mount %s, which has no versions", secretName, mount.path)
+	}
+
+	secretValues, err := vc.client.Logical().ReadWithContext(vc.ctx, mount.apiPath(secretName, "metadata"))
 	if err != nil {
 		return version, fmt.Errorf("reading secret from Vault for %s failed: %w", secretName, err)
 	}
 
+	if secretValues == nil {
+		return version, fmt.Errorf("secret values returned from Vault are <nil> for %s: %w", secretName, ErrSecretNotFound)
+	}
+
 	if _, ok := secretValues.Data["current_version"]; !ok {
 		return version, fmt.Errorf("current version not available for secret %s", secretName)
 	}
@@ -117,22 +342,44 @@ func (vc *vaultClient) SecretVersionFromVault(secretName string) (int64, error)
 	return version, fmt.Errorf("current version is of type: %t and value: %v for secret %s", secretValues.Data["current_version"], secretValues.Data["current_version"], secretName)
 }
 
+// tracer starts a span named name and returns the func that ends it.
 type tracer interface {
-	trace(string) func()
+	trace(name string) func()
 }
 
+// trace starts a span as a child of the span in vc.ctx, and makes it the
+// current span of vc until the returned func ends it, so it should be called
+// as defer vc.tracer.trace(name)().
 func (vc *vaultClient) trace(name string) func() {
 	if !vc.config.traceEnabled {
 		return func() {}
 	}
 
-	var span *trace.Span
-	vc.ctx, span = trace.StartSpan(
-		vc.ctx,
-		name,
-	)
+	parent := vc.ctx
+
+	var span trace.Span
+	vc.ctx, span = vc.telemetry().tracer.Start(vc.ctx, name)
+
+	return func() {
+		span.End()
+		vc.ctx = parent
+	}
+}
+
+// newHTTPClient returns an HTTP client like the one of api.DefaultConfig,
+// without the settings it reads from the environment.
+func newHTTPClient() *http.Client {
+	client := cleanhttp.DefaultPooledClient()
+	transport := client.Transport.(*http.Transport)
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+
+	// redirects are handled by the api package
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
 
-	return func() { defer span.End() }
+	return client
 }
 
 func extractListData(secret *api.Secret) ([]interface{}, bool) {
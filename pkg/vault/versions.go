@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// SecretMetadata is the metadata Vault keeps for a KV v2 secret.
+type SecretMetadata struct {
+	CurrentVersion int64
+	OldestVersion  int64
+	MaxVersions    int64
+	CreatedTime    time.Time
+	UpdatedTime    time.Time
+	CustomMetadata map[string]string
+	// Versions lists the versions Vault still keeps, oldest first.
+	Versions []VersionMetadata
+}
+
+// VersionMetadata describes one version of a KV v2 secret.
+type VersionMetadata struct {
+	Version     int64
+	CreatedTime time.Time
+	// DeletionTime is zero unless the version has been soft deleted.
+	DeletionTime time.Time
+	Destroyed    bool
+}
+
+// Deleted reports whether the version has been soft deleted or destroyed, so
+// its data can no longer be read.
+func (v VersionMetadata) Deleted() bool {
+	return v.Destroyed || !v.DeletionTime.IsZero()
+}
+
+// secretMetadata reads the metadata of a KV v2 secret.
+func (vc *vaultClient) secretMetadata(secretName string) (*SecretMetadata, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/secretMetadata", vc.config.tracePrefix))()
+
+	mount := vc.kvMount(secretName)
+	if !mount.isV2() {
+		return nil, fmt.Errorf("secret %s is on KV v1 mount %s, which has no metadata", secretName, mount.path)
+	}
+
+	secret, err := vc.client.Logical().ReadWithContext(vc.ctx, mount.apiPath(secretName, "metadata"))
+	if err != nil {
+		return nil, fmt.Errorf("reading secret metadata from Vault for %s: %w", secretName, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret metadata returned from Vault is <nil> for %s: %w", secretName, ErrSecretNotFound)
+	}
+
+	return parseSecretMetadata(secret.Data), nil
+}
+
+func parseSecretMetadata(data map[string]interface{}) *SecretMetadata {
+	m := &SecretMetadata{
+		CurrentVersion: jsonInt(data["current_version"]),
+		OldestVersion:  jsonInt(data["oldest_version"]),
+		MaxVersions:    jsonInt(data["max_versions"]),
+		CreatedTime:    jsonTime(data["created_time"]),
+		UpdatedTime:    jsonTime(data["updated_time"]),
+		CustomMetadata: map[string]string{},
+	}
+
+	if custom, ok := data["custom_metadata"].(map[string]interface{}); ok {
+		for k, v := range custom {
+			m.CustomMetadata[k], _ = v.(string)
+		}
+	}
+
+	versions, _ := data["versions"].(map[string]interface{})
+	for number, v := range versions {
+		version, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		fields, _ := v.(map[string]interface{})
+		destroyed, _ := fields["destroyed"].(bool)
+		m.Versions = append(m.Versions, VersionMetadata{
+			Version:      version,
+			CreatedTime:  jsonTime(fields["created_time"]),
+			DeletionTime: jsonTime(fields["deletion_time"]),
+			Destroyed:    destroyed,
+		})
+	}
+
+	sort.Slice(m.Versions, func(i, j int) bool {
+		return m.Versions[i].Version < m.Versions[j].Version
+	})
+
+	return m
+}
+
+// rollback writes the data of an earlier version of a KV v2 secret as its new
+// latest version.
+func (vc *vaultClient) rollback(secretName string, version int64) (*api.Secret, error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/rollback", vc.config.tracePrefix))()
+
+	return vc.retryConflicts(func() (*api.Secret, error) {
+		current, err := vc.SecretVersionFromVault(secretName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify secret at %s: %w", secretName, err)
+		}
+
+		data, _, err := vc.readSecretVersion(secretName, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version %d of %s: %w", version, secretName, err)
+		}
+
+		secret, err := vc.writeCAS(secretName, data, current)
+		if err != nil {
+			return secret, fmt.Errorf("failed to roll back %s to version %d: %w", secretName, version, err)
+		}
+
+		return secret, nil
+	})
+}
+
+// jsonInt returns the integer value of a json.Number, or 0.
+func jsonInt(v interface{}) int64 {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0
+	}
+
+	i, _ := n.Int64()
+
+	return i
+}
+
+// jsonTime returns the time in an RFC 3339 string, or the zero time.
+func jsonTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	t, _ := time.Parse(time.RFC3339Nano, s)
+
+	return t
+}
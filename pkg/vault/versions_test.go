@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSecretVersions(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/versions/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	for _, value := range []string{"second", "third"} {
+		_, err := rootVaultClient.Logical().Write(secretEngine, map[string]interface{}{
+			"data": map[string]interface{}{secretKey: value},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := rootVaultClient.Logical().Write("kv/metadata/versions/foo", map[string]interface{}{
+		"custom_metadata": map[string]interface{}{"owner": "platform"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, cluster)
+
+	t.Run("read version", testReadVersion(c))
+	t.Run("read missing version", testReadMissingVersion(c))
+	t.Run("metadata", testSecretMetadata(c))
+	t.Run("rollback", testRollback(c))
+}
+
+func testReadVersion(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		data, err := c.GetSecretVersion(context.Background(), secretEngine, 1)
+		is.NoErr(err)
+		is.Equal(data[secretKey], secretValue)
+
+		data, err = c.GetSecretVersion(context.Background(), "kv/versions/foo", 2)
+		is.NoErr(err)
+		is.Equal(data[secretKey], "second")
+	}
+}
+
+func testReadMissingVersion(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := c.GetSecretVersion(context.Background(), secretEngine, 42)
+		is.True(errors.Is(err, ErrSecretNotFound))
+	}
+}
+
+func testSecretMetadata(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		metadata, err := c.GetSecretMetadata(context.Background(), secretEngine)
+		is.NoErr(err)
+
+		is.Equal(metadata.CurrentVersion, int64(3))
+		is.Equal(metadata.CustomMetadata["owner"], "platform")
+		is.True(!metadata.CreatedTime.IsZero())
+		is.Equal(len(metadata.Versions), 3)
+
+		for i, v := range metadata.Versions {
+			is.Equal(v.Version, int64(i+1))
+			is.True(!v.CreatedTime.IsZero())
+			is.True(!v.Deleted())
+		}
+	}
+}
+
+func testRollback(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(c.RollbackSecret(context.Background(), secretEngine, 1))
+
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(data[secretKey], secretValue)
+
+		metadata, err := c.GetSecretMetadata(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(metadata.CurrentVersion, int64(4))
+	}
+}
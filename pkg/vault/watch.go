@@ -0,0 +1,167 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SecretChange is sent by Watch when the version of a secret changes, or when
+// checking it fails.
+type SecretChange struct {
+	Path       string
+	OldVersion int64
+	NewVersion int64
+	// Old and New hold the data of the secret at OldVersion and NewVersion.
+	Old map[string]string
+	New map[string]string
+	// Added, Removed and Changed list the keys that differ between Old and
+	// New, sorted.
+	Added   []string
+	Removed []string
+	Changed []string
+	// Err is set when the secret could not be checked. The other fields
+	// then only hold the path and the versions, if known.
+	Err error
+}
+
+// watchedSecret is the last version and data Watch has seen of a secret.
+type watchedSecret struct {
+	version int64
+	data    map[string]string
+}
+
+// watch polls the current version of every path every interval, reads the
+// data of the paths whose version moved and sends a change for each of them
+// on changes. It returns when ctx is done.
+func (vc *vaultClient) watch(ctx context.Context, paths []string, seen []watchedSecret, interval time.Duration, changes chan<- SecretChange) {
+	defer close(changes)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for i, path := range paths {
+			change, ok := vc.checkSecret(path, &seen[i])
+			if !ok {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case changes <- change:
+			}
+		}
+	}
+}
+
+// checkSecret compares the current version of path with the last version
+// seen. If it moved, it reads the new data, updates seen and returns the
+// change.
+func (vc *vaultClient) checkSecret(path string, seen *watchedSecret) (SecretChange, bool) {
+	change := SecretChange{Path: path, OldVersion: seen.version}
+
+	current, err := vc.SecretVersionFromVault(path)
+	if err != nil {
+		change.Err = err
+		return change, true
+	}
+
+	if current == seen.version {
+		return change, false
+	}
+
+	// the version is seen even if it can't be read, e.g. because it has
+	// been deleted, so the error is sent once and not on every tick
+	seen.version = current
+	change.NewVersion = current
+
+	data, version, err := vc.readSecret(path)
+	if err != nil {
+		change.Err = err
+		return change, true
+	}
+
+	m, err := stringValues(path, data)
+	if err != nil {
+		change.Err = err
+		return change, true
+	}
+
+	vc.cache.invalidate(vc.cacheKey(path))
+
+	change.NewVersion = version
+	change.Old = seen.data
+	change.New = m
+	change.Added, change.Removed, change.Changed = diffKeys(seen.data, m)
+
+	*seen = watchedSecret{version: version, data: m}
+
+	return change, true
+}
+
+// diffKeys returns the keys that are only in new, only in old, and in both
+// with different values.
+func diffKeys(old, new map[string]string) (added, removed, changed []string) {
+	for k, v := range new {
+		oldValue, ok := old[k]
+		switch {
+		case !ok:
+			added = append(added, k)
+		case oldValue != v:
+			changed = append(changed, k)
+		}
+	}
+
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+
+	return added, removed, changed
+}
+
+// startWatch reads the current version and data of every path and starts
+// watching them for changes.
+func (vc *vaultClient) startWatch(ctx context.Context, paths []string, interval time.Duration) (<-chan SecretChange, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+
+	seen := make([]watchedSecret, len(paths))
+	for i, path := range paths {
+		if mount := vc.kvMount(path); !mount.isV2() {
+			return nil, fmt.Errorf("secret %s is on KV v1 mount %s, which has no versions to watch", path, mount.path)
+		}
+
+		data, version, err := vc.readSecret(path)
+		if err != nil {
+			return nil, fmt.Errorf("watching secret: %w", err)
+		}
+
+		if seen[i].data, err = stringValues(path, data); err != nil {
+			return nil, fmt.Errorf("watching secret: %w", err)
+		}
+		seen[i].version = version
+	}
+
+	changes := make(chan SecretChange)
+	// the watcher gets its own copy of vc, as tracing rewrites vc.ctx and the
+	// span of the caller ends when it returns
+	go vc.withContext(ctx).watch(ctx, paths, seen, interval, changes)
+
+	return changes, nil
+}
@@ -0,0 +1,190 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	hashivault "github.com/hashicorp/vault/vault"
+	"github.com/matryer/is"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWatch(t *testing.T) {
+	secretKey, secretValue, secretEngine = "myKey", "myValue", "kv/data/watch/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	rootVaultClient := cluster.Cores[0].Client
+	c := newTestClient(t, cluster)
+
+	t.Run("secret changes", testWatchChanges(c, rootVaultClient))
+	t.Run("deleted version", testWatchDeleted(c, rootVaultClient))
+	t.Run("tracing", testWatchTracing(cluster, rootVaultClient))
+	t.Run("missing secret", testWatchMissing(c))
+}
+
+func testWatchChanges(c *Client, root *api.Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		changes, err := c.Watch(ctx, []string{secretEngine}, 20*time.Millisecond)
+		is.NoErr(err)
+
+		_, err = root.Logical().Write(secretEngine, map[string]interface{}{
+			"data": map[string]interface{}{"password": "rotated", "user": "app"},
+		})
+		is.NoErr(err)
+
+		select {
+		case change := <-changes:
+			is.NoErr(change.Err)
+			is.Equal(change.Path, secretEngine)
+			is.Equal(change.OldVersion, int64(1))
+			is.Equal(change.NewVersion, int64(2))
+			is.Equal(change.Old, map[string]string{secretKey: secretValue})
+			is.Equal(change.New, map[string]string{"password": "rotated", "user": "app"})
+			is.Equal(change.Added, []string{"password", "user"})
+			is.Equal(change.Removed, []string{secretKey})
+			is.Equal(len(change.Changed), 0)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change received")
+		}
+
+		cancel()
+		for range changes {
+		}
+	}
+}
+
+func testWatchTracing(cluster *hashivault.TestCluster, root *api.Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		spans := tracetest.NewSpanRecorder()
+		c := newTestClient(t, cluster, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		path := "kv/data/watch/traced"
+		_, err := root.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{"key": "v1"},
+		})
+		is.NoErr(err)
+
+		changes, err := c.Watch(ctx, []string{path}, 10*time.Millisecond)
+		is.NoErr(err)
+
+		_, err = root.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{"key": "v2"},
+		})
+		is.NoErr(err)
+
+		select {
+		case change := <-changes:
+			is.NoErr(change.Err)
+			is.Equal(change.NewVersion, int64(2))
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change received")
+		}
+
+		cancel()
+		for range changes {
+		}
+
+		var watch sdktrace.ReadOnlySpan
+		for _, span := range spans.Ended() {
+			if span.Name() == "vault/Watch" {
+				watch = span
+			}
+		}
+		is.True(watch != nil)
+
+		// polls are not children of the Watch span, which has ended
+		var polls int
+		for _, span := range spans.Ended() {
+			if span.Name() != "vault/SecretVersionFromVault" {
+				continue
+			}
+
+			polls++
+			is.True(span.Parent().SpanID() != watch.SpanContext().SpanID())
+		}
+		is.True(polls > 0)
+	}
+}
+
+func testWatchDeleted(c *Client, root *api.Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		path := "kv/data/watch/deleted"
+		_, err := root.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{"key": "v1"},
+		})
+		is.NoErr(err)
+
+		seen := watchedSecret{version: 1, data: map[string]string{"key": "v1"}}
+
+		_, err = root.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{"key": "v2"},
+		})
+		is.NoErr(err)
+
+		_, err = root.Logical().Delete(path)
+		is.NoErr(err)
+
+		change, ok := c.vc.checkSecret(path, &seen)
+		is.True(ok)
+		is.True(errors.Is(change.Err, ErrSecretNotFound))
+		is.Equal(change.OldVersion, int64(1))
+		is.Equal(change.NewVersion, int64(2))
+
+		// the deleted version is not reported again
+		_, ok = c.vc.checkSecret(path, &seen)
+		is.True(!ok)
+
+		_, err = root.Logical().Write(path, map[string]interface{}{
+			"data": map[string]interface{}{"key": "v3"},
+		})
+		is.NoErr(err)
+
+		change, ok = c.vc.checkSecret(path, &seen)
+		is.True(ok)
+		is.NoErr(change.Err)
+		is.Equal(change.OldVersion, int64(2))
+		is.Equal(change.NewVersion, int64(3))
+		is.Equal(change.Old, map[string]string{"key": "v1"})
+		is.Equal(change.Changed, []string{"key"})
+	}
+}
+
+func testWatchMissing(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := c.Watch(context.Background(), []string{"kv/data/watch/missing"}, time.Second)
+		is.True(err != nil)
+	}
+}
+
+func TestDiffKeys(t *testing.T) {
+	is := is.New(t)
+
+	added, removed, changed := diffKeys(
+		map[string]string{"same": "1", "changed": "old", "removed": "x"},
+		map[string]string{"same": "1", "changed": "new", "added": "y"},
+	)
+
+	is.Equal(added, []string{"added"})
+	is.Equal(removed, []string{"removed"})
+	is.Equal(changed, []string{"changed"})
+}
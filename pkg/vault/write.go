@@ -1,27 +1,107 @@
 package vault
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 )
 
 func (vc *vaultClient) write(engine string, m map[string]string) (*api.Secret, error) {
-	vc.tracer.trace(fmt.Sprintf("%s/write", vc.config.tracePrefix))
-
 	data := make(map[string]interface{}, len(m))
 	for k, v := range m {
 		data[k] = v
 	}
 
-	secrets := map[string]interface{}{
-		"data": data,
+	return vc.writeData(engine, data)
+}
+
+// writeData writes data to a KV v1 or v2 secret, replacing its current data.
+func (vc *vaultClient) writeData(engine string, data map[string]interface{}) (*api.Secret, error) {
+	return vc.writeKV(engine, data, nil)
+}
+
+// writeCAS writes data to a KV v2 secret only if its current version is still
+// version, returning a ConflictError otherwise. KV v1 has no versions, so the
+// data is written unconditionally there.
+func (vc *vaultClient) writeCAS(engine string, data map[string]interface{}, version int64) (*api.Secret, error) {
+	return vc.writeKV(engine, data, &version)
+}
+
+func (vc *vaultClient) writeKV(engine string, data map[string]interface{}, cas *int64) (_ *api.Secret, err error) {
+	defer vc.tracer.trace(fmt.Sprintf("%s/write", vc.config.tracePrefix))()
+
+	mount := vc.kvMount(engine)
+	defer vc.observe(opWrite, time.Now(), &err, pathAttributes(engine, mount)...)
+
+	secrets := data
+	if mount.isV2() {
+		secrets = map[string]interface{}{
+			"data": data,
+		}
+		if cas != nil {
+			secrets["options"] = map[string]interface{}{
+				"cas": *cas,
+			}
+		}
 	}
 
-	secret, err := vc.client.Logical().Write(engine, secrets)
+	secret, err := vc.client.Logical().WriteWithContext(vc.ctx, mount.apiPath(engine, "data"), secrets)
+	vc.cache.invalidate(vc.cacheKey(engine))
 	if err != nil {
+		if cas != nil && isCASMismatch(err) {
+			err = &ConflictError{Path: engine, Version: *cas, Err: err}
+		}
+
 		return secret, fmt.Errorf("failed to write data to %s: %w", engine, err)
 	}
 
 	return secret, nil
 }
+
+// isCASMismatch reports whether err is Vault rejecting a write because the
+// check-and-set version did not match the current version of the secret.
+func isCASMismatch(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	for _, e := range respErr.Errors {
+		if strings.Contains(e, "check-and-set") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxConflictBackoff caps the wait between retries of a conflicting write.
+const maxConflictBackoff = 10 * time.Second
+
+// retryConflicts runs op, a read-modify-write of a secret, and runs it again
+// when it fails with a ConflictError, as many times as the client is
+// configured to retry. The wait between attempts doubles every time, up to
+// maxConflictBackoff.
+func (vc *vaultClient) retryConflicts(op func() (*api.Secret, error)) (*api.Secret, error) {
+	backoff := vc.config.conflictBackoff
+	for attempt := 0; ; attempt++ {
+		secret, err := op()
+		if err == nil || !errors.Is(err, ErrConflict) || attempt >= vc.config.conflictRetries {
+			return secret, err
+		}
+
+		vc.logger().Debug("retrying write after conflict", "attempt", attempt+1, "backoff", backoff, "error", err)
+
+		select {
+		case <-vc.ctx.Done():
+			return secret, err
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxConflictBackoff)
+	}
+}
@@ -3,8 +3,13 @@ package vault
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	hashivault "github.com/hashicorp/vault/vault"
 	"github.com/matryer/is"
 )
 
@@ -70,3 +75,68 @@ func write_missingPath(vc *vaultClient) func(*testing.T) {
 		is.NoErr(err)
 	}
 }
+
+func TestWriteCAS(t *testing.T) {
+	secretKey, secretValue, secretEngine = "existing-key", "foo", "kv/data/cas/foo"
+
+	cluster := createTestVault(t)
+	defer cluster.Cleanup()
+
+	vc := &vaultClient{
+		config: &config{},
+		ctx:    context.Background(),
+		client: cluster.Cores[0].Client,
+	}
+	vc.tracer = vc
+
+	t.Run("stale version conflicts", writeCAS_stale(vc))
+	t.Run("concurrent creates retry", writeCAS_retry(cluster))
+}
+
+func writeCAS_stale(vc *vaultClient) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		data, version, err := vc.readSecret(secretEngine)
+		is.NoErr(err)
+		is.Equal(version, int64(1))
+
+		_, err = vc.writeCAS(secretEngine, data, version)
+		is.NoErr(err)
+
+		_, err = vc.writeCAS(secretEngine, data, version)
+		is.True(errors.Is(err, ErrConflict))
+
+		var conflictErr *ConflictError
+		is.True(errors.As(err, &conflictErr))
+		is.Equal(conflictErr.Path, secretEngine)
+		is.Equal(conflictErr.Version, version)
+	}
+}
+
+func writeCAS_retry(cluster *hashivault.TestCluster) func(*testing.T) {
+	return func(t *testing.T) {
+		is := is.New(t)
+
+		c := newTestClient(t, cluster, WithConflictRetry(50, 5*time.Millisecond))
+
+		var wg sync.WaitGroup
+		errs := make([]error, 10)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = c.CreateSecret(context.Background(), secretEngine, fmt.Sprintf("key-%d", i), "value")
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			is.NoErr(err)
+		}
+
+		data, err := c.GetSecretData(context.Background(), secretEngine)
+		is.NoErr(err)
+		is.Equal(len(data), len(errs)+1)
+	}
+}